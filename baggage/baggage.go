@@ -0,0 +1,212 @@
+/*
+Package baggage propagates rogerr metadata across process boundaries in a
+W3C Baggage header (https://www.w3.org/TR/baggage/).
+
+Only the metadata keys on the codec's allow-list leave the process, and
+only those keys are accepted from incoming headers:
+
+	codec := baggage.NewCodec(baggage.WithAllowedKeys("tenant.id", "request.*"))
+	client := &http.Client{Transport: codec.Transport(http.DefaultTransport)}
+	mux := http.NewServeMux()
+	server := &http.Server{Handler: codec.Middleware(mux)}
+
+Metadata values are sent as their %v string, so they are always strings
+once extracted.
+*/
+package baggage
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/kinbiko/rogerr"
+)
+
+const (
+	// MaxMembers is the maximum number of list-members in a baggage header,
+	// as required by the W3C Baggage specification.
+	MaxMembers = 64
+	// MaxBytes is the maximum size of a baggage header in bytes, as required
+	// by the W3C Baggage specification.
+	MaxBytes = 8192
+)
+
+// Codec converts between rogerr context metadata and baggage header values.
+type Codec struct {
+	allowed []string
+	errors  *rogerr.ErrorHandler
+}
+
+// Option is a function that configures a Codec.
+type Option func(*Codec)
+
+// WithAllowedKeys configures the metadata keys that are propagated.
+// Keys may be given exactly or as path.Match glob patterns, e.g. "tenant.*".
+func WithAllowedKeys(patterns ...string) Option {
+	return func(c *Codec) {
+		c.allowed = append(c.allowed, patterns...)
+	}
+}
+
+// WithErrorHandler configures the rogerr.ErrorHandler whose redaction rules
+// apply to the metadata that is propagated.
+func WithErrorHandler(errorHandler *rogerr.ErrorHandler) Option {
+	return func(c *Codec) {
+		c.errors = errorHandler
+	}
+}
+
+// NewCodec creates a new Codec with the given options.
+// By default no keys are allowed, so nothing is propagated until keys are
+// configured with WithAllowedKeys.
+func NewCodec(opts ...Option) *Codec {
+	c := &Codec{errors: rogerr.NewErrorHandler()}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Encode returns a baggage header value with the allowed metadata of the
+// given context, ordered by key. Members that are not valid baggage keys are
+// left out, as are members beyond the size limits of the specification.
+// Returns an empty string if there is no allowed metadata.
+func (c *Codec) Encode(ctx context.Context) string {
+	return c.encode(ctx, "")
+}
+
+// Decode returns a new context with the allowed members of the given
+// baggage header value attached as metadata. Members that are malformed or
+// beyond the size limits of the specification are ignored.
+func (c *Codec) Decode(ctx context.Context, header string) context.Context {
+	md := map[string]interface{}{}
+	for _, m := range parse(header) {
+		if c.allows(m.key) {
+			md[m.key] = m.value
+		}
+	}
+	if len(md) == 0 {
+		return ctx
+	}
+	return rogerr.WithMetadata(ctx, md)
+}
+
+// encode returns the given baggage header value with the allowed metadata of
+// the given context appended, skipping keys that the header already has.
+func (c *Codec) encode(ctx context.Context, existing string) string {
+	members := parse(existing)
+	present := make(map[string]bool, len(members))
+	var b strings.Builder
+	for _, m := range members {
+		present[m.key] = true
+		appendMember(&b, m.raw)
+	}
+
+	md := c.errors.SelectContextMetadata(ctx, func(k string) bool {
+		return c.allows(k) && isToken(k) && !present[k]
+	})
+	keys := make([]string, 0, len(md))
+	for k := range md {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	count := len(members)
+	for _, k := range keys {
+		member := k + "=" + escape(fmt.Sprintf("%v", md[k]))
+		if count >= MaxMembers || b.Len()+len(member)+1 > MaxBytes {
+			continue
+		}
+		appendMember(&b, member)
+		count++
+	}
+	return b.String()
+}
+
+func (c *Codec) allows(key string) bool {
+	for _, pattern := range c.allowed {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// member is a single list-member of a baggage header.
+type member struct {
+	key   string
+	value string
+	raw   string // The member as it appeared in the header, including properties
+}
+
+// parse returns the well-formed members of the given baggage header value,
+// up to the size limits of the specification.
+func parse(header string) []member {
+	members := []member{}
+	size := 0
+	for _, raw := range strings.Split(header, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		kv, _, _ := strings.Cut(raw, ";")
+		k, v, ok := strings.Cut(kv, "=")
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if !ok || !isToken(k) {
+			continue
+		}
+		value, err := url.PathUnescape(v)
+		if err != nil {
+			continue
+		}
+		if size += len(raw) + 1; len(members) >= MaxMembers || size-1 > MaxBytes {
+			break
+		}
+		members = append(members, member{key: k, value: value, raw: raw})
+	}
+	return members
+}
+
+func appendMember(b *strings.Builder, member string) {
+	if b.Len() > 0 {
+		b.WriteByte(',')
+	}
+	b.WriteString(member)
+}
+
+// isToken reports whether s is a valid baggage key, which is an RFC 7230 token.
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		isAlnum := c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+		if !isAlnum && !strings.ContainsRune("!#$%&'*+-.^_`|~", rune(c)) {
+			return false
+		}
+	}
+	return true
+}
+
+// escape percent-encodes the bytes of s that are not baggage-octets, as well
+// as the percent sign itself.
+func escape(s string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c <= ' ' || c >= 0x7f || c == '"' || c == ',' || c == ';' || c == '\\' || c == '%' {
+			b.WriteByte('%')
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&0xf])
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,126 @@
+package baggage_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kinbiko/rogerr"
+	"github.com/kinbiko/rogerr/baggage"
+)
+
+func TestEncode(t *testing.T) {
+	ctx := rogerr.WithMetadata(context.Background(), map[string]interface{}{
+		"tenant.id":  42,
+		"request.id": "a b,c;d%e\"f\\g",
+		"user.name":  "Ærøskøbing",
+		"password":   "hunter2",
+	})
+
+	for name, tc := range map[string]struct {
+		opts []baggage.Option
+		exp  string
+	}{
+		"nothing is allowed by default": {exp: ""},
+		"allowed keys are percent-encoded and sorted": {
+			opts: []baggage.Option{baggage.WithAllowedKeys("tenant.id", "request.*", "user.name")},
+			exp:  "request.id=a%20b%2Cc%3Bd%25e%22f%5Cg,tenant.id=42,user.name=%C3%86r%C3%B8sk%C3%B8bing",
+		},
+		"redacted keys are not propagated": {
+			opts: []baggage.Option{
+				baggage.WithAllowedKeys("*"),
+				baggage.WithErrorHandler(rogerr.NewErrorHandler(rogerr.WithRedactedKeys("password", "request.id", "user.name"))),
+			},
+			exp: "tenant.id=42",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got := baggage.NewCodec(tc.opts...).Encode(ctx); got != tc.exp {
+				t.Errorf("expected %q but got %q", tc.exp, got)
+			}
+		})
+	}
+
+	t.Run("invalid keys are skipped", func(t *testing.T) {
+		ctx := rogerr.WithMetadata(context.Background(), map[string]interface{}{"bad key": 1, "ok": 2})
+		if got := baggage.NewCodec(baggage.WithAllowedKeys("*")).Encode(ctx); got != "ok=2" {
+			t.Errorf("expected %q but got %q", "ok=2", got)
+		}
+	})
+
+	t.Run("values of other keys are not computed", func(t *testing.T) {
+		ctx := rogerr.WithMetadata(context.Background(), map[string]interface{}{
+			"tenant.id": rogerr.Lazy(func() any { return 42 }),
+			"db.stats": rogerr.Lazy(func() any {
+				t.Error("expected the value of a key that isn't allowed not to be computed")
+				return nil
+			}),
+		})
+		if got := baggage.NewCodec(baggage.WithAllowedKeys("tenant.id")).Encode(ctx); got != "tenant.id=42" {
+			t.Errorf("expected %q but got %q", "tenant.id=42", got)
+		}
+	})
+
+	t.Run("size limits", func(t *testing.T) {
+		md := map[string]interface{}{}
+		for i := 0; i < 2*baggage.MaxMembers; i++ {
+			md[fmt.Sprintf("k%03d", i)] = i
+		}
+		got := baggage.NewCodec(baggage.WithAllowedKeys("*")).Encode(rogerr.WithMetadata(context.Background(), md))
+		if n := strings.Count(got, ",") + 1; n != baggage.MaxMembers {
+			t.Errorf("expected %d members but got %d", baggage.MaxMembers, n)
+		}
+
+		long := strings.Repeat("x", baggage.MaxBytes/3)
+		md = map[string]interface{}{"a": long, "b": long, "c": long, "d": "small"}
+		got = baggage.NewCodec(baggage.WithAllowedKeys("*")).Encode(rogerr.WithMetadata(context.Background(), md))
+		if len(got) > baggage.MaxBytes || !strings.HasSuffix(got, ",d=small") || strings.Contains(got, "c=") {
+			t.Errorf("expected members that don't fit to be skipped but got %d bytes ending %q", len(got), got[len(got)-10:])
+		}
+	})
+}
+
+func TestDecode(t *testing.T) {
+	codec := baggage.NewCodec(baggage.WithAllowedKeys("tenant.id", "request.*"))
+
+	for header, exp := range map[string]map[string]interface{}{
+		"tenant.id=42": {"tenant.id": "42"},
+		" tenant.id = 42 ; prop=1 , request.id=a%20b": {"tenant.id": "42", "request.id": "a b"},
+		"tenant.id=42,other=1":                        {"tenant.id": "42"},
+		"tenant.id,request.id=%zz,=1,,request.x=ok":   {"request.x": "ok"},
+	} {
+		t.Run(header, func(t *testing.T) {
+			if got := rogerr.ContextMetadata(codec.Decode(context.Background(), header)); !reflect.DeepEqual(got, exp) {
+				t.Errorf("expected %v but got %v", exp, got)
+			}
+		})
+	}
+
+	t.Run("no allowed members", func(t *testing.T) {
+		ctx := context.Background()
+		if got := codec.Decode(ctx, "other=1"); got != ctx {
+			t.Error("expected the given context to be returned")
+		}
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		ctx := rogerr.WithMetadata(context.Background(), map[string]interface{}{"tenant.id": "a,b;c=d é", "request.id": 1})
+		got := rogerr.ContextMetadata(codec.Decode(context.Background(), codec.Encode(ctx)))
+		if exp := map[string]interface{}{"tenant.id": "a,b;c=d é", "request.id": "1"}; !reflect.DeepEqual(got, exp) {
+			t.Errorf("expected %v but got %v", exp, got)
+		}
+	})
+
+	t.Run("size limits", func(t *testing.T) {
+		members := make([]string, 2*baggage.MaxMembers)
+		for i := range members {
+			members[i] = fmt.Sprintf("request.%03d=%d", i, i)
+		}
+		got := rogerr.ContextMetadata(codec.Decode(context.Background(), strings.Join(members, ",")))
+		if len(got) != baggage.MaxMembers {
+			t.Errorf("expected %d members but got %d", baggage.MaxMembers, len(got))
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package baggage
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// HeaderName is the name of the W3C Baggage header.
+const HeaderName = "Baggage"
+
+// Inject adds the allowed metadata of the given context to the baggage
+// header of h. Members already in the header, e.g. ones added by a tracing
+// library, are kept and take precedence.
+func (c *Codec) Inject(ctx context.Context, h http.Header) {
+	existing := strings.Join(h.Values(HeaderName), ",")
+	if v := c.encode(ctx, existing); v != "" {
+		h.Set(HeaderName, v)
+	}
+}
+
+// Extract returns a new context with the allowed members of the baggage
+// header of h attached as metadata.
+func (c *Codec) Extract(ctx context.Context, h http.Header) context.Context {
+	return c.Decode(ctx, strings.Join(h.Values(HeaderName), ","))
+}
+
+// Transport returns an http.RoundTripper that injects the allowed metadata
+// of each request's context into its baggage header before passing it on
+// to next, or to http.DefaultTransport if next is nil.
+func (c *Codec) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		// RoundTrippers must not modify the given request
+		r = r.Clone(r.Context())
+		c.Inject(r.Context(), r.Header)
+		return next.RoundTrip(r)
+	})
+}
+
+// Middleware returns an http.Handler that attaches the allowed members of
+// each request's baggage header to its context as metadata before calling next.
+func (c *Codec) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(c.Extract(r.Context(), r.Header)))
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
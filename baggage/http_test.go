@@ -0,0 +1,56 @@
+package baggage_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kinbiko/rogerr"
+	"github.com/kinbiko/rogerr/baggage"
+)
+
+func TestInject(t *testing.T) {
+	codec := baggage.NewCodec(baggage.WithAllowedKeys("*"))
+	ctx := rogerr.WithMetadata(context.Background(), map[string]interface{}{"tenant.id": 42, "trace.sampled": "no"})
+
+	h := http.Header{}
+	h.Add(baggage.HeaderName, "trace.sampled=yes;prop")
+	h.Add(baggage.HeaderName, "other=1")
+	codec.Inject(ctx, h)
+	if got, exp := h.Values(baggage.HeaderName), "trace.sampled=yes;prop,other=1,tenant.id=42"; len(got) != 1 || got[0] != exp {
+		t.Errorf("expected %q but got %q", exp, got)
+	}
+
+	h = http.Header{}
+	codec.Inject(context.Background(), h)
+	if _, ok := h[baggage.HeaderName]; ok {
+		t.Errorf("expected no header without metadata but got %v", h)
+	}
+}
+
+func TestTransportAndMiddleware(t *testing.T) {
+	codec := baggage.NewCodec(baggage.WithAllowedKeys("tenant.id"))
+
+	var got map[string]interface{}
+	srv := httptest.NewServer(codec.Middleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		got = rogerr.ContextMetadata(r.Context())
+	})))
+	defer srv.Close()
+
+	ctx := rogerr.WithMetadata(context.Background(), map[string]interface{}{"tenant.id": 42, "secret": "s"})
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	client := &http.Client{Transport: codec.Transport(nil)}
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	res.Body.Close()
+
+	if got["tenant.id"] != "42" || got["secret"] != nil {
+		t.Errorf("expected only tenant.id to be propagated but got %v", got)
+	}
+	if req.Header.Get(baggage.HeaderName) != "" {
+		t.Error("expected the original request not to be modified")
+	}
+}
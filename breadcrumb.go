@@ -0,0 +1,154 @@
+package rogerr
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// maxBreadcrumbs is the number of most recent breadcrumbs that are kept.
+const maxBreadcrumbs = 100
+
+// BreadcrumbEntry is an event that happened before an error, recorded with Breadcrumb.
+type BreadcrumbEntry struct {
+	Time     time.Time              `json:"time"`           // When the breadcrumb was recorded
+	Category string                 `json:"category"`       // The kind of event, e.g. "http" or "db"
+	Message  string                 `json:"message"`        // What happened
+	Data     map[string]interface{} `json:"data,omitempty"` // Any additional data
+}
+
+// breadcrumbNode is an element of a persistent linked list of breadcrumbs,
+// with the most recent breadcrumb at the head.
+type breadcrumbNode struct {
+	parent *breadcrumbNode
+	entry  BreadcrumbEntry
+	depth  int
+}
+
+// Breadcrumb records an event on the breadcrumb trail of the given context,
+// so that the sequence of events that led to an error can be retrieved from
+// it with Breadcrumbs. Only the most recent 100 breadcrumbs are kept.
+// Returns a new context with the breadcrumb attached, or nil if the given ctx was nil.
+// The given map is copied, so later changes to it will not affect the returned context.
+func Breadcrumb(ctx context.Context, category, msg string, data map[string]interface{}) context.Context {
+	if ctx == nil {
+		return nil
+	}
+	var d map[string]interface{}
+	if data != nil {
+		d = make(map[string]interface{}, len(data))
+		for k, v := range data {
+			d[k] = v
+		}
+	}
+	return withBreadcrumbs(ctx, BreadcrumbEntry{Time: time.Now(), Category: category, Message: msg, Data: d})
+}
+
+// Breadcrumbs returns the breadcrumb trail of the contexts given when
+// wrapping the given error, ordered from the oldest breadcrumb to the most
+// recent. Every rogerr layer in the error chain is considered, and the data
+// of the breadcrumbs is redacted like metadata is by Metadata.
+func Breadcrumbs(err error) []BreadcrumbEntry {
+	return exportHandler(err).Breadcrumbs(err)
+}
+
+// Breadcrumbs is like the package level Breadcrumbs, except that the data
+// of the breadcrumbs is redacted according to this handler's configuration.
+func (h *ErrorHandler) Breadcrumbs(err error) []BreadcrumbEntry {
+	trails := [][]BreadcrumbEntry{}
+	seen := map[*breadcrumbNode]bool{}
+	for _, e := range rErrors(err) {
+		if e.ctx != nil {
+			trails = append(trails, h.breadcrumbs(getCtxData(e.ctx).breadcrumbs, seen, &e.errorHandler().redaction))
+		}
+	}
+	return mergeBreadcrumbs(trails)
+}
+
+// withBreadcrumbs returns a new context with the given entries appended to its breadcrumb trail.
+// The trail is rebuilt once it has grown to twice the number of breadcrumbs
+// that are kept, so that older breadcrumbs can be garbage collected.
+func withBreadcrumbs(ctx context.Context, entries ...BreadcrumbEntry) context.Context {
+	cd := getCtxData(ctx)
+	head := cd.breadcrumbs
+	for _, entry := range entries {
+		if head != nil && head.depth >= 2*maxBreadcrumbs {
+			head = compactBreadcrumbs(head)
+		}
+		n := &breadcrumbNode{parent: head, entry: entry, depth: 1}
+		if head != nil {
+			n.depth = head.depth + 1
+		}
+		head = n
+	}
+	cd.breadcrumbs = head
+	return context.WithValue(ctx, ctxDataKey, &cd)
+}
+
+// compactBreadcrumbs returns a new trail with the most recent breadcrumbs of
+// the given trail, leaving room for one more.
+func compactBreadcrumbs(head *breadcrumbNode) *breadcrumbNode {
+	recent := make([]*breadcrumbNode, 0, maxBreadcrumbs-1)
+	for n := head; n != nil && len(recent) < maxBreadcrumbs-1; n = n.parent {
+		recent = append(recent, n)
+	}
+	var compacted *breadcrumbNode
+	for i := len(recent) - 1; i >= 0; i-- {
+		compacted = &breadcrumbNode{parent: compacted, entry: recent[i].entry, depth: len(recent) - i}
+	}
+	return compacted
+}
+
+// breadcrumbs returns the kept breadcrumbs of the given trail that haven't
+// been seen yet, from the oldest to the most recent, with redacted data.
+// If the trail belongs to a layer of an error, the data is first redacted
+// according to the given rules of the handler that created the layer.
+func (h *ErrorHandler) breadcrumbs(head *breadcrumbNode, seen map[*breadcrumbNode]bool, layer *redactor) []BreadcrumbEntry {
+	entries := []BreadcrumbEntry{}
+	for n := head; n != nil && len(entries) < maxBreadcrumbs && !seen[n]; n = n.parent {
+		seen[n] = true
+		entry := n.entry
+		if entry.Data != nil {
+			data := make(map[string]interface{}, len(entry.Data))
+			for k, v := range entry.Data {
+				data[k] = v
+			}
+			if layer != nil {
+				data = layer.redactLayer(data)
+			}
+			entry.Data = h.redaction.redact(data)
+		}
+		entries = append(entries, entry)
+	}
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries
+}
+
+// mergeBreadcrumbs combines the given trails into one ordered by time,
+// without duplicates, keeping only the most recent breadcrumbs.
+func mergeBreadcrumbs(trails [][]BreadcrumbEntry) []BreadcrumbEntry {
+	merged := []BreadcrumbEntry{}
+	for _, trail := range trails {
+		merged = append(merged, trail...)
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Time.Before(merged[j].Time) })
+
+	deduped := merged[:0]
+	for _, entry := range merged {
+		if n := len(deduped); n > 0 && sameBreadcrumb(deduped[n-1], entry) {
+			continue
+		}
+		deduped = append(deduped, entry)
+	}
+	if len(deduped) > maxBreadcrumbs {
+		deduped = deduped[len(deduped)-maxBreadcrumbs:]
+	}
+	return deduped
+}
+
+func sameBreadcrumb(a, b BreadcrumbEntry) bool {
+	return a.Time.Equal(b.Time) && a.Category == b.Category && a.Message == b.Message && reflect.DeepEqual(a.Data, b.Data)
+}
@@ -0,0 +1,101 @@
+package rogerr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func messages(entries []BreadcrumbEntry) []string {
+	msgs := make([]string, len(entries))
+	for i, e := range entries {
+		msgs[i] = e.Message
+	}
+	return msgs
+}
+
+func TestBreadcrumbs(t *testing.T) {
+	h := NewErrorHandler(WithStacktrace(false))
+
+	t.Run("records breadcrumbs in order", func(t *testing.T) {
+		data := map[string]interface{}{"status": 200}
+		ctx := Breadcrumb(context.Background(), "http", "GET /users", data)
+		data["status"] = 500
+		ctx = Breadcrumb(ctx, "db", "SELECT users", nil)
+
+		got := Breadcrumbs(h.Wrap(ctx, nil, "oops"))
+		if exp := []string{"GET /users", "SELECT users"}; !reflect.DeepEqual(messages(got), exp) {
+			t.Fatalf("expected breadcrumbs %v but got %v", exp, messages(got))
+		}
+		if got[0].Category != "http" || got[0].Data["status"] != 200 || got[0].Time.IsZero() {
+			t.Errorf("unexpected breadcrumb %+v", got[0])
+		}
+		if got[0].Time.After(got[1].Time) {
+			t.Error("expected breadcrumbs to be ordered by time")
+		}
+	})
+
+	t.Run("sibling contexts don't share breadcrumbs", func(t *testing.T) {
+		parent := Breadcrumb(context.Background(), "app", "start", nil)
+		a := Breadcrumb(parent, "app", "a", nil)
+		_ = Breadcrumb(parent, "app", "b", nil)
+		if got := messages(Breadcrumbs(h.Wrap(a, nil, "oops"))); !reflect.DeepEqual(got, []string{"start", "a"}) {
+			t.Errorf("expected [start a] but got %v", got)
+		}
+	})
+
+	t.Run("merges the breadcrumbs of every layer", func(t *testing.T) {
+		ctx := Breadcrumb(context.Background(), "app", "first", nil)
+		inner := h.Wrap(ctx, nil, "inner")
+		ctx = Breadcrumb(ctx, "app", "second", nil)
+		err := fmt.Errorf("fmt: %w", h.Wrap(ctx, inner, "outer"))
+		if got := messages(Breadcrumbs(err)); !reflect.DeepEqual(got, []string{"first", "second"}) {
+			t.Errorf("expected [first second] but got %v", got)
+		}
+	})
+
+	t.Run("keeps the most recent breadcrumbs", func(t *testing.T) {
+		ctx := context.Background()
+		for i := 0; i < 5*maxBreadcrumbs+1; i++ {
+			ctx = Breadcrumb(ctx, "loop", fmt.Sprint(i), nil)
+		}
+		if depth := getCtxData(ctx).breadcrumbs.depth; depth > 2*maxBreadcrumbs {
+			t.Errorf("expected the trail to be compacted but its depth is %d", depth)
+		}
+		got := Breadcrumbs(h.Wrap(ctx, nil, "oops"))
+		if len(got) != maxBreadcrumbs {
+			t.Fatalf("expected %d breadcrumbs but got %d", maxBreadcrumbs, len(got))
+		}
+		if got[0].Message != fmt.Sprint(4*maxBreadcrumbs+1) || got[len(got)-1].Message != fmt.Sprint(5*maxBreadcrumbs) {
+			t.Errorf("expected the most recent breadcrumbs but got %s to %s", got[0].Message, got[len(got)-1].Message)
+		}
+	})
+
+	t.Run("redacts data", func(t *testing.T) {
+		h := NewErrorHandler(WithRedactedKeys("password"))
+		ctx := Breadcrumb(context.Background(), "auth", "login", map[string]interface{}{"user": "bob", "password": "hunter2"})
+		got := Breadcrumbs(h.Wrap(ctx, nil, "oops"))
+		if exp := map[string]interface{}{"user": "bob"}; !reflect.DeepEqual(got[0].Data, exp) {
+			t.Errorf("expected data %v but got %v", exp, got[0].Data)
+		}
+	})
+
+	t.Run("preserves metadata and code", func(t *testing.T) {
+		ctx := WithCode(WithMetadatum(context.Background(), "k", "v"), CodeNotFound)
+		err := h.Wrap(Breadcrumb(ctx, "app", "crumb", nil), nil, "oops")
+		if Metadata(err)["k"] != "v" || CodeOf(err) != CodeNotFound {
+			t.Error("expected breadcrumbs not to affect metadata or code")
+		}
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		if Breadcrumb(nil, "app", "crumb", nil) != nil { //nolint:staticcheck // testing nil ctx
+			t.Error("expected nil context")
+		}
+		if got := Breadcrumbs(errors.New("plain")); len(got) != 0 {
+			t.Errorf("expected no breadcrumbs but got %v", got)
+		}
+	})
+}
@@ -0,0 +1,113 @@
+package rogerr
+
+import (
+	"context"
+	"fmt"
+)
+
+// Code is a machine-readable error code.
+// The codes and their numeric values are the canonical gRPC status codes,
+// so that errors can be translated to gRPC responses without importing gRPC.
+type Code int
+
+const (
+	CodeOK                 Code = 0  // Not an error
+	CodeCanceled           Code = 1  // The operation was canceled, typically by the caller
+	CodeUnknown            Code = 2  // Unknown error, e.g. an error without a code
+	CodeInvalidArgument    Code = 3  // The client specified an invalid argument
+	CodeDeadlineExceeded   Code = 4  // The deadline expired before the operation could complete
+	CodeNotFound           Code = 5  // Some requested entity was not found
+	CodeAlreadyExists      Code = 6  // The entity that a client attempted to create already exists
+	CodePermissionDenied   Code = 7  // The caller does not have permission to execute the operation
+	CodeResourceExhausted  Code = 8  // Some resource has been exhausted, e.g. a rate limit
+	CodeFailedPrecondition Code = 9  // The system is not in a state required for the operation
+	CodeAborted            Code = 10 // The operation was aborted, e.g. due to a concurrency conflict
+	CodeOutOfRange         Code = 11 // The operation was attempted past the valid range
+	CodeUnimplemented      Code = 12 // The operation is not implemented or supported
+	CodeInternal           Code = 13 // An invariant expected by the system has been broken
+	CodeUnavailable        Code = 14 // The service is currently unavailable
+	CodeDataLoss           Code = 15 // Unrecoverable data loss or corruption
+	CodeUnauthenticated    Code = 16 // The request does not have valid authentication credentials
+)
+
+// WithCode attaches the given code to the context, so that errors wrapped
+// with the returned context have this code.
+// It's typically used right before wrapping:
+//
+//	return handler.Wrap(rogerr.WithCode(ctx, rogerr.CodeNotFound), err, "user not found")
+//
+// Returns a new context with the code attached, or nil if the given ctx was nil.
+func WithCode(ctx context.Context, code Code) context.Context {
+	if ctx == nil {
+		return nil
+	}
+	cd := getCtxData(ctx)
+	cd.code = &code
+	return context.WithValue(ctx, ctxDataKey, &cd)
+}
+
+// CodeOf returns the code of the outermost rogerr layer in the given error
+// chain that has a code.
+// Returns CodeOK for a nil error, and CodeUnknown if no layer has a code.
+func CodeOf(err error) Code {
+	if err == nil {
+		return CodeOK
+	}
+	for _, e := range rErrors(err) {
+		if code, ok := e.code(); ok {
+			return code
+		}
+	}
+	return CodeUnknown
+}
+
+// String returns the name of the code, e.g. "not_found".
+func (c Code) String() string {
+	names := [...]string{
+		"ok", "canceled", "unknown", "invalid_argument", "deadline_exceeded",
+		"not_found", "already_exists", "permission_denied", "resource_exhausted",
+		"failed_precondition", "aborted", "out_of_range", "unimplemented",
+		"internal", "unavailable", "data_loss", "unauthenticated",
+	}
+	if c < 0 || int(c) >= len(names) {
+		return fmt.Sprintf("Code(%d)", int(c))
+	}
+	return names[c]
+}
+
+// HTTPStatus returns the HTTP status code that corresponds to the code.
+// Unrecognised codes map to 500 Internal Server Error.
+func (c Code) HTTPStatus() int {
+	statuses := [...]int{
+		200, // ok
+		499, // canceled: Client Closed Request
+		500, // unknown
+		400, // invalid_argument
+		504, // deadline_exceeded
+		404, // not_found
+		409, // already_exists
+		403, // permission_denied
+		429, // resource_exhausted
+		400, // failed_precondition
+		409, // aborted
+		400, // out_of_range
+		501, // unimplemented
+		500, // internal
+		503, // unavailable
+		500, // data_loss
+		401, // unauthenticated
+	}
+	if c < 0 || int(c) >= len(statuses) {
+		return 500
+	}
+	return statuses[c]
+}
+
+// GRPCCode returns the canonical gRPC status code number that corresponds to the code.
+// Unrecognised codes map to 2 (Unknown).
+func (c Code) GRPCCode() int {
+	if c < CodeOK || c > CodeUnauthenticated {
+		return int(CodeUnknown)
+	}
+	return int(c)
+}
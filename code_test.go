@@ -0,0 +1,108 @@
+package rogerr_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/kinbiko/rogerr"
+)
+
+func TestCodeOf(t *testing.T) {
+	ctx := context.Background()
+
+	for name, tc := range map[string]struct {
+		err error
+		exp rogerr.Code
+	}{
+		"nil error":            {err: nil, exp: rogerr.CodeOK},
+		"non-rogerr error":     {err: errors.New("oops"), exp: rogerr.CodeUnknown},
+		"rogerr without code":  {err: rogerr.Wrap(ctx, nil, "oops"), exp: rogerr.CodeUnknown},
+		"rogerr with code":     {err: rogerr.Wrap(rogerr.WithCode(ctx, rogerr.CodeNotFound), nil, "oops"), exp: rogerr.CodeNotFound},
+		"through fmt wrappers": {err: fmt.Errorf("fmt: %w", rogerr.Wrap(rogerr.WithCode(ctx, rogerr.CodeNotFound), nil, "oops")), exp: rogerr.CodeNotFound},
+		"outermost code wins": {
+			err: rogerr.Wrap(rogerr.WithCode(ctx, rogerr.CodeUnavailable), rogerr.Wrap(rogerr.WithCode(ctx, rogerr.CodeNotFound), nil, "inner"), "outer"),
+			exp: rogerr.CodeUnavailable,
+		},
+		"inner code found when outer has none": {
+			err: rogerr.Wrap(ctx, rogerr.Wrap(rogerr.WithCode(ctx, rogerr.CodeNotFound), nil, "inner"), "outer"),
+			exp: rogerr.CodeNotFound,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got := rogerr.CodeOf(tc.err); got != tc.exp {
+				t.Errorf("expected code %s but got %s", tc.exp, got)
+			}
+		})
+	}
+
+	t.Run("code does not affect metadata", func(t *testing.T) {
+		err := rogerr.Wrap(rogerr.WithCode(rogerr.WithMetadatum(ctx, "k", "v"), rogerr.CodeNotFound), nil, "oops")
+		if got := rogerr.Metadata(err); len(got) != 1 {
+			t.Errorf("expected only the metadatum but got %v", got)
+		}
+	})
+
+	t.Run("survives JSON round trip", func(t *testing.T) {
+		data, err := json.Marshal(rogerr.Wrap(rogerr.WithCode(ctx, rogerr.CodeNotFound), nil, "oops"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rebuilt, err := rogerr.UnmarshalError(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := rogerr.CodeOf(rebuilt); got != rogerr.CodeNotFound {
+			t.Errorf("expected code %s but got %s", rogerr.CodeNotFound, got)
+		}
+	})
+
+	t.Run("nil ctx", func(t *testing.T) {
+		if got := rogerr.WithCode(nil, rogerr.CodeNotFound); got != nil { //nolint:staticcheck // Testing that we don't do a dumb when users do a dumb
+			t.Errorf("expected nil ctx but got %v", got)
+		}
+	})
+}
+
+func TestCodeMappings(t *testing.T) {
+	for _, tc := range []struct {
+		code rogerr.Code
+		name string
+		http int
+		grpc int
+	}{
+		{rogerr.CodeOK, "ok", 200, 0},
+		{rogerr.CodeCanceled, "canceled", 499, 1},
+		{rogerr.CodeUnknown, "unknown", 500, 2},
+		{rogerr.CodeInvalidArgument, "invalid_argument", 400, 3},
+		{rogerr.CodeDeadlineExceeded, "deadline_exceeded", 504, 4},
+		{rogerr.CodeNotFound, "not_found", 404, 5},
+		{rogerr.CodeAlreadyExists, "already_exists", 409, 6},
+		{rogerr.CodePermissionDenied, "permission_denied", 403, 7},
+		{rogerr.CodeResourceExhausted, "resource_exhausted", 429, 8},
+		{rogerr.CodeFailedPrecondition, "failed_precondition", 400, 9},
+		{rogerr.CodeAborted, "aborted", 409, 10},
+		{rogerr.CodeOutOfRange, "out_of_range", 400, 11},
+		{rogerr.CodeUnimplemented, "unimplemented", 501, 12},
+		{rogerr.CodeInternal, "internal", 500, 13},
+		{rogerr.CodeUnavailable, "unavailable", 503, 14},
+		{rogerr.CodeDataLoss, "data_loss", 500, 15},
+		{rogerr.CodeUnauthenticated, "unauthenticated", 401, 16},
+		{rogerr.Code(99), "Code(99)", 500, 2},
+		{rogerr.Code(-1), "Code(-1)", 500, 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.code.String(); got != tc.name {
+				t.Errorf("expected name %s but got %s", tc.name, got)
+			}
+			if got := tc.code.HTTPStatus(); got != tc.http {
+				t.Errorf("expected HTTP status %d but got %d", tc.http, got)
+			}
+			if got := tc.code.GRPCCode(); got != tc.grpc {
+				t.Errorf("expected gRPC code %d but got %d", tc.grpc, got)
+			}
+		})
+	}
+}
@@ -2,13 +2,31 @@ package rogerr
 
 import (
 	"context"
-	"errors"
+	"reflect"
 )
 
 type ctxKey int
 
 const ctxDataKey ctxKey = 0
 
+// ctxData is the value stored in a context under ctxDataKey.
+// It is never mutated once stored: deriving a new context creates a new
+// ctxData that shares its ancestors' state, so that sibling contexts never
+// observe each other's metadata.
+type ctxData struct {
+	metadata    *metadataNode
+	code        *Code
+	breadcrumbs *breadcrumbNode
+}
+
+// metadataNode is an element of a persistent linked list of metadata.
+// Entries in nodes closer to the head take precedence over their parents'.
+type metadataNode struct {
+	parent    *metadataNode
+	data      map[string]interface{}
+	namespace Namespace // The namespace that the keys of data are qualified with, if any
+}
+
 // WithMetadatum attaches the given key and value to the rogerr metadata
 // associated with this context.
 // Returns a new context with the metadatum attached, or nil if the given ctx was nil.
@@ -19,37 +37,164 @@ func WithMetadatum(ctx context.Context, key string, value interface{}) context.C
 // WithMetadata attaches the given keys and values to the rogerr metadata
 // associated with this context.
 // Returns a new context with the metadata attached, or nil if the given ctx was nil.
+// The given map is copied, so later changes to it will not affect the returned context.
 func WithMetadata(ctx context.Context, data map[string]interface{}) context.Context {
-	if ctx == nil {
-		return nil
-	}
-	md := getOrInitializeMetadata(ctx)
-	for k, v := range data {
-		md[k] = v
+	return Namespace("").WithMetadata(ctx, data)
+}
+
+// ContextMetadata returns a copy of the rogerr metadata associated with the given context.
+// Returns nil if the given ctx was nil.
+func ContextMetadata(ctx context.Context) map[string]interface{} {
+	md := getMetadata(ctx)
+	for k, v := range md {
+		md[k] = resolve(v)
 	}
-	return context.WithValue(ctx, ctxDataKey, md)
+	return md
+}
+
+// MergeStrategy determines how metadata is combined when more than one
+// layer of an error chain has metadata under the same key.
+type MergeStrategy int
+
+const (
+	// MergeOutermostWins keeps the value from the layer closest to the top of the error chain.
+	MergeOutermostWins MergeStrategy = iota
+	// MergeInnermostWins keeps the value from the layer closest to the root cause.
+	MergeInnermostWins
+	// MergeKeepAll keeps every distinct value. Keys with conflicting values
+	// map to a []interface{} ordered from the outermost to the innermost layer.
+	MergeKeepAll
+)
+
+// MetadataLayer is the metadata attached by a single rogerr wrap in an error chain.
+type MetadataLayer struct {
+	Message  string                 // The message given when wrapping
+	Metadata map[string]interface{} // The metadata of the context given when wrapping
 }
 
 // Metadata pulls out all the metadata known by this package as a
 // map[key]value from the given error.
+// Every rogerr layer in the error chain is considered, including the
+// branches of errors created with errors.Join. If several layers have
+// metadata under the same key, the outermost value wins.
+// The metadata of each layer is redacted according to the configuration of
+// the ErrorHandler that created it, and then according to that of the
+// handler that created the outermost rogerr layer. Use ErrorHandler.Metadata
+// to redact it according to another handler instead.
+// The returned map is a copy, and can be modified freely by the caller.
 func Metadata(err error) map[string]interface{} {
-	rErr := &rError{}
+	return exportHandler(err).redaction.redact(mergeMetadata(metadataLayers(err), MergeOutermostWins))
+}
 
-	// Yes, that's a double pointer. The error type is a struct pointer, and
-	// errors.As requires a pointer to a type that implements the error
-	// interface, which is *Error, hence passing **Error here.
-	errors.As(err, &rErr)
-	return getOrInitializeMetadata(rErr.ctx)
+// MetadataLayers returns the metadata of every rogerr layer in the given
+// error chain, ordered from the outermost layer to the innermost.
+// The metadata is redacted as with Metadata.
+func MetadataLayers(err error) []MetadataLayer {
+	h := exportHandler(err)
+	layers := metadataLayers(err)
+	for i := range layers {
+		layers[i].Metadata = h.redaction.redact(layers[i].Metadata)
+	}
+	return layers
 }
 
-func getOrInitializeMetadata(ctx context.Context) map[string]interface{} {
-	if ctx == nil {
-		return nil
+// metadataLayers returns the unredacted metadata of every rogerr layer in
+// the given error chain, ordered from the outermost layer to the innermost.
+func metadataLayers(err error) []MetadataLayer {
+	layers := []MetadataLayer{}
+	for _, e := range rErrors(err) {
+		layers = append(layers, MetadataLayer{Message: e.msg, Metadata: e.metadata()})
+	}
+	return layers
+}
+
+// mergeMetadata combines the metadata of the given layers according to the given strategy.
+// Returns nil if no layer had a context attached.
+func mergeMetadata(layers []MetadataLayer, strategy MergeStrategy) map[string]interface{} {
+	var m map[string]interface{}
+	kept := map[string][]interface{}{}
+	for i := range layers {
+		if strategy == MergeInnermostWins {
+			i = len(layers) - 1 - i
+		}
+		if layers[i].Metadata == nil {
+			continue
+		}
+		if m == nil {
+			m = map[string]interface{}{}
+		}
+		for k, v := range layers[i].Metadata {
+			if strategy == MergeKeepAll {
+				kept[k] = appendDistinct(kept[k], v)
+			} else if _, ok := m[k]; !ok {
+				m[k] = v
+			}
+		}
+	}
+	for k, values := range kept {
+		if len(values) == 1 {
+			m[k] = values[0]
+		} else {
+			m[k] = keptValues(values)
+		}
 	}
+	return m
+}
+
+// keptValues are the distinct values of a key merged with MergeKeepAll.
+// They become a []interface{} when the merged metadata is redacted, which
+// resolves them like any other value.
+type keptValues []interface{}
 
-	m := map[string]interface{}{}
+// appendDistinct adds v to the given values unless an equal value is already present.
+func appendDistinct(values []interface{}, v interface{}) []interface{} {
+	for _, existing := range values {
+		if reflect.DeepEqual(existing, v) {
+			return values
+		}
+	}
+	return append(values, v)
+}
+
+// getCtxData returns a copy of the ctxData stored in the given context, or
+// the zero value if there is none.
+func getCtxData(ctx context.Context) ctxData {
 	if val := ctx.Value(ctxDataKey); val != nil {
-		m = val.(map[string]interface{}) //nolint:errcheck // this package owns the ctx key type so this cast is safe.
+		return *val.(*ctxData) //nolint:errcheck // this package owns the ctx key type so this cast is safe.
 	}
+	return ctxData{}
+}
+
+// getMetadata flattens the metadata attached to the given context into a new map.
+// Returns nil if the given ctx is nil.
+func getMetadata(ctx context.Context) map[string]interface{} {
+	m, _ := getNamespacedMetadata(ctx)
 	return m
 }
+
+// getNamespacedMetadata flattens the metadata attached to the given context
+// into a new map, along with the namespaces of the keys that have one.
+// Returns nil maps if the given ctx is nil.
+func getNamespacedMetadata(ctx context.Context) (map[string]interface{}, map[string]Namespace) {
+	if ctx == nil {
+		return nil, nil
+	}
+
+	nodes := []*metadataNode{}
+	for n := getCtxData(ctx).metadata; n != nil; n = n.parent {
+		nodes = append(nodes, n)
+	}
+
+	m, namespaces := map[string]interface{}{}, map[string]Namespace{}
+	for i := len(nodes) - 1; i >= 0; i-- {
+		for k, v := range nodes[i].data {
+			m[k] = v
+			if nodes[i].namespace != "" {
+				namespaces[k] = nodes[i].namespace
+			} else {
+				delete(namespaces, k)
+			}
+		}
+	}
+	return m, namespaces
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/kinbiko/rogerr"
@@ -123,3 +124,114 @@ func TestMetadata(t *testing.T) {
 		}
 	})
 }
+
+func TestMetadataIsolation(t *testing.T) {
+	t.Run("sibling contexts do not see each other's metadata", func(t *testing.T) {
+		base := rogerr.WithMetadatum(context.Background(), "base", true)
+		left := rogerr.WithMetadatum(base, "left", 1)
+		right := rogerr.WithMetadatum(base, "right", 2)
+
+		if _, ok := rogerr.Metadata(rogerr.Wrap(left, nil))["right"]; ok {
+			t.Error("expected left context not to contain metadata added to its sibling")
+		}
+		if _, ok := rogerr.Metadata(rogerr.Wrap(right, nil))["left"]; ok {
+			t.Error("expected right context not to contain metadata added to its sibling")
+		}
+		if got := len(rogerr.Metadata(rogerr.Wrap(base, nil))); got != 1 {
+			t.Errorf("expected parent context to be unaffected by its children, but had %d keys", got)
+		}
+	})
+
+	t.Run("mutating returned metadata does not affect the error", func(t *testing.T) {
+		err := rogerr.Wrap(rogerr.WithMetadatum(context.Background(), "key", "value"), nil)
+		rogerr.Metadata(err)["key"] = "changed"
+		if got := rogerr.Metadata(err)["key"]; got != "value" {
+			t.Errorf("expected metadata to be unchanged but got %v", got)
+		}
+	})
+
+	t.Run("mutating the given map does not affect the context", func(t *testing.T) {
+		data := map[string]interface{}{"key": "value"}
+		ctx := rogerr.WithMetadata(context.Background(), data)
+		data["key"] = "changed"
+		if got := rogerr.Metadata(rogerr.Wrap(ctx, nil))["key"]; got != "value" {
+			t.Errorf("expected metadata to be unchanged but got %v", got)
+		}
+	})
+
+	t.Run("concurrent branches off a shared context", func(t *testing.T) {
+		base := rogerr.WithMetadatum(context.Background(), "base", true)
+		var wg sync.WaitGroup
+		for i := range 100 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ctx := rogerr.WithMetadatum(base, "goroutine", i)
+				ctx = rogerr.WithMetadatum(ctx, fmt.Sprintf("key-%d", i), i)
+				md := rogerr.Metadata(rogerr.Wrap(ctx, nil))
+				if len(md) != 3 || md["goroutine"] != i {
+					t.Errorf("expected only this goroutine's metadata but got %v", md)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+func BenchmarkWithMetadatum(b *testing.B) {
+	ctx := context.Background()
+	for i := range 1000 {
+		ctx = rogerr.WithMetadatum(ctx, fmt.Sprintf("key-%d", i), i)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rogerr.WithMetadatum(ctx, "key", i)
+	}
+}
+
+func TestMetadataAcrossLayers(t *testing.T) {
+	libCtx := rogerr.WithMetadata(context.Background(), map[string]interface{}{"lib": "mylib", "id": "lib-id"})
+	appCtx := rogerr.WithMetadata(context.Background(), map[string]interface{}{"app": "myapp", "id": "app-id"})
+
+	libErr := rogerr.Wrap(libCtx, errors.New("low level"), "lib failed")
+	err := rogerr.Wrap(appCtx, fmt.Errorf("fmt: %w", libErr), "app failed")
+
+	t.Run("keys from every layer are merged with the outermost winning", func(t *testing.T) {
+		md := rogerr.Metadata(err)
+		for k, v := range map[string]interface{}{"lib": "mylib", "app": "myapp", "id": "app-id"} {
+			if md[k] != v {
+				t.Errorf("expected metadata at key '%s' to be <%v> but was <%v>", k, v, md[k])
+			}
+		}
+	})
+
+	t.Run("errors.Join branches are included", func(t *testing.T) {
+		other := rogerr.Wrap(rogerr.WithMetadatum(context.Background(), "other", 1), nil, "other failed")
+		md := rogerr.Metadata(errors.Join(err, other))
+		for _, k := range []string{"lib", "app", "other"} {
+			if _, ok := md[k]; !ok {
+				t.Errorf("expected metadata to contain key '%s' but got %v", k, md)
+			}
+		}
+	})
+
+	t.Run("per-layer breakdown", func(t *testing.T) {
+		layers := rogerr.MetadataLayers(err)
+		if len(layers) != 2 {
+			t.Fatalf("expected 2 layers but got %d", len(layers))
+		}
+		if got := layers[0]; got.Message != "app failed" || got.Metadata["id"] != "app-id" {
+			t.Errorf("unexpected outermost layer: %+v", got)
+		}
+		if got := layers[1]; got.Message != "lib failed" || got.Metadata["id"] != "lib-id" {
+			t.Errorf("unexpected innermost layer: %+v", got)
+		}
+	})
+
+	t.Run("non-rogerr errors have no layers", func(t *testing.T) {
+		if got := rogerr.MetadataLayers(errors.New("plain")); len(got) != 0 {
+			t.Errorf("expected no layers but got %+v", got)
+		}
+	})
+}
@@ -3,12 +3,23 @@ package rogerr
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
 )
 
 type rError struct {
-	err        error
-	ctx        context.Context
-	msg        string
+	err     error
+	ctx     context.Context
+	msg     string
+	handler *ErrorHandler
+
+	// The stacktrace is captured as program counters, and only resolved
+	// into frames the first time it's needed, as most errors never are.
+	pcs        []uintptr
+	truncated  bool
+	fromPanic  bool
+	resolve    sync.Once
 	stacktrace []Frame
 }
 
@@ -34,9 +45,189 @@ func (e *rError) Unwrap() error {
 	return e.err
 }
 
+// LogValue implements slog.LogValuer, so that logging the error with any slog
+// handler gives a group with the error message, metadata, wrapped causes,
+// breadcrumbs, and optionally the in-app stacktrace frames.
+func (e *rError) LogValue() slog.Value {
+	h := e.errorHandler()
+	attrs := []slog.Attr{slog.String("message", e.Error())}
+
+	if md := h.MetadataSet(e); md.Len() > 0 {
+		mdAttrs := make([]slog.Attr, 0, md.Len())
+		for k, v := range md.All() {
+			mdAttrs = append(mdAttrs, slog.Any(k, v))
+		}
+		attrs = append(attrs, slog.Attr{Key: "metadata", Value: slog.GroupValue(mdAttrs...)})
+	}
+
+	causes := []string{}
+	walkLayers(e.err, func(layer error) {
+		if msg := layerMessage(layer); msg != "" {
+			causes = append(causes, msg)
+		}
+	})
+	if len(causes) > 0 {
+		attrs = append(attrs, slog.Any("causes", causes))
+	}
+
+	if breadcrumbs := h.Breadcrumbs(e); len(breadcrumbs) > 0 {
+		attrs = append(attrs, slog.Any("breadcrumbs", breadcrumbs))
+	}
+
+	if h.logStacktrace {
+		frames := []string{}
+		for _, f := range h.Stacktrace(e) {
+			if f.InApp {
+				frames = append(frames, fmt.Sprintf("%s (%s:%d)", f.Function, f.File, f.Line))
+			}
+		}
+		if len(frames) > 0 {
+			attrs = append(attrs, slog.Any("stacktrace", frames))
+		}
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// hasStacktrace reports whether this error has a stacktrace, without resolving it.
+func (e *rError) hasStacktrace() bool {
+	return len(e.pcs) > 0 || len(e.stacktrace) > 0
+}
+
+// frames returns the stacktrace of this error, resolving it on first use.
+func (e *rError) frames() []Frame {
+	e.resolve.Do(func() {
+		if e.pcs == nil {
+			return
+		}
+		h := e.errorHandler()
+		frames := resolveFrames(e.pcs, h.inApp.classifier())
+		if e.fromPanic {
+			frames = trimPanicFrames(frames)
+		} else {
+			frames = trimRogerrFrames(frames)
+		}
+		e.stacktrace = h.stack.apply(frames, e.truncated)
+	})
+	return e.stacktrace
+}
+
+// metadata returns the metadata of the context given when this error was
+// created, not including the metadata of any wrapped errors.
+// The metadata is redacted according to the ErrorHandler that created this
+// error, but resolving it and redacting it further is left to the
+// ErrorHandler that exports it.
+func (e *rError) metadata() map[string]interface{} {
+	return e.errorHandler().redaction.redactLayer(getMetadata(e.ctx))
+}
+
+// code returns the code attached to the context given when this error was
+// created, and whether there was one.
+func (e *rError) code() (Code, bool) {
+	if e.ctx == nil {
+		return 0, false
+	}
+	if code := getCtxData(e.ctx).code; code != nil {
+		return *code, true
+	}
+	return 0, false
+}
+
+// exportHandler returns the ErrorHandler that exports the given error when
+// no handler is given explicitly, e.g. by the package level Metadata function,
+// which is the handler that created its outermost rogerr layer.
+func exportHandler(err error) *ErrorHandler {
+	if errs := rErrors(err); len(errs) > 0 {
+		return errs[0].errorHandler()
+	}
+	return NewErrorHandler()
+}
+
+// errorHandler returns the ErrorHandler that created this error, or a
+// default handler if the error wasn't created by an ErrorHandler.
+func (e *rError) errorHandler() *ErrorHandler {
+	if e.handler == nil {
+		return NewErrorHandler()
+	}
+	return e.handler
+}
+
 // Wrap wraps errors with the default error handler settings.
 // See ErrorHandler.Wrap for more details.
 // Deprecated: Use ErrorHandler.Wrap instead.
 func Wrap(ctx context.Context, err error, msgAndFmtArgs ...any) error {
 	return NewErrorHandler().Wrap(ctx, err, msgAndFmtArgs...)
 }
+
+// rErrors returns every rError in the given error's tree, ordered from the
+// outermost to the innermost.
+func rErrors(err error) []*rError {
+	found := []*rError{}
+	walkLayers(err, func(layer error) {
+		if rErr, ok := layer.(*rError); ok {
+			found = append(found, rErr)
+		}
+	})
+	return found
+}
+
+// walkLayers calls fn for every error in the given error's tree, ordered from
+// the outermost to the innermost. Both Unwrap() error and Unwrap() []error are
+// followed, but errors that only join other errors, e.g. with errors.Join,
+// are represented by their branches rather than passed to fn themselves.
+func walkLayers(err error, fn func(error)) {
+	for ; err != nil; err = unwrapOne(err) {
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, e := range joined.Unwrap() {
+				walkLayers(e, fn)
+			}
+			return
+		}
+		fn(err)
+	}
+}
+
+// hasStacktrace reports whether any rogerr layer in the given error chain has a stacktrace.
+func hasStacktrace(err error) bool {
+	for _, e := range rErrors(err) {
+		if e.hasStacktrace() {
+			return true
+		}
+	}
+	return false
+}
+
+// unwrapOne returns the error wrapped by err with Unwrap() error, or nil if
+// there is no such error.
+func unwrapOne(err error) error {
+	if u, ok := err.(interface{ Unwrap() error }); ok {
+		return u.Unwrap()
+	}
+	return nil
+}
+
+// layerMessage returns the part of the given error's message that was added
+// by this error itself, rather than by the error it wraps.
+func layerMessage(err error) string {
+	if rErr, ok := err.(*rError); ok {
+		return rErr.msg
+	}
+	msg := err.Error()
+	if cause := unwrapOne(err); cause != nil {
+		msg = strings.TrimSuffix(msg, cause.Error())
+		msg = strings.TrimSuffix(msg, ": ")
+	}
+	return msg
+}
+
+// RootCause follows the chain of single-wrapped errors to the innermost error.
+// Errors that wrap several errors, such as those created with errors.Join,
+// are considered to be the root cause.
+func RootCause(err error) error {
+	for {
+		u, ok := err.(interface{ Unwrap() error })
+		if !ok || u.Unwrap() == nil {
+			return err
+		}
+		err = u.Unwrap()
+	}
+}
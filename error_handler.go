@@ -2,13 +2,19 @@ package rogerr
 
 import (
 	"context"
-	"errors"
 	"fmt"
 )
 
 // ErrorHandler provides configurable error handling with optional stacktrace capture.
 type ErrorHandler struct {
-	stacktrace bool
+	stacktrace          bool
+	merge               MergeStrategy
+	logStacktrace       bool
+	redaction           redactor
+	inApp               inAppRules
+	stack               stackRules
+	innermostStacktrace bool
+	reporting           pipeline
 }
 
 // Option is a function that configures an ErrorHandler.
@@ -21,11 +27,39 @@ func WithStacktrace(enabled bool) Option {
 	}
 }
 
+// WithInnermostStacktrace configures whether only the innermost stacktrace of
+// an error chain is of interest. If enabled, Wrap doesn't capture a
+// stacktrace when the wrapped error already has one, and Stacktrace returns
+// the innermost stacktrace rather than the outermost.
+func WithInnermostStacktrace(enabled bool) Option {
+	return func(h *ErrorHandler) {
+		h.innermostStacktrace = enabled
+	}
+}
+
+// WithLogStacktrace configures whether the in-app stacktrace frames are
+// included when errors are logged with log/slog.
+func WithLogStacktrace(enabled bool) Option {
+	return func(h *ErrorHandler) {
+		h.logStacktrace = enabled
+	}
+}
+
+// WithMergeStrategy configures how ErrorHandler.Metadata resolves metadata
+// keys that are present in more than one layer of an error chain.
+func WithMergeStrategy(strategy MergeStrategy) Option {
+	return func(h *ErrorHandler) {
+		h.merge = strategy
+	}
+}
+
 // NewErrorHandler creates a new ErrorHandler with the given options.
-// By default, stacktrace capture is enabled.
+// By default, stacktrace capture is enabled and the outermost metadata value wins.
 func NewErrorHandler(opts ...Option) *ErrorHandler {
 	h := &ErrorHandler{
 		stacktrace: true, // stacktrace enabled by default
+		merge:      MergeOutermostWins,
+		stack:      stackRules{maxDepth: defaultMaxStackDepth},
 	}
 	for _, opt := range opts {
 		opt(h)
@@ -34,12 +68,63 @@ func NewErrorHandler(opts ...Option) *ErrorHandler {
 }
 
 // Stacktrace extracts the stacktrace from an error if it was created with ErrorHandler.
+// By default this is the stacktrace of the outermost rogerr layer that has one,
+// but with WithInnermostStacktrace it's that of the innermost layer that has one.
+// Stacktraces are captured cheaply when wrapping, and resolved into frames
+// the first time they are extracted.
+// If this handler has been configured with in-app rules, e.g. with
+// WithInAppPackages, the frames are classified according to this handler's
+// rules rather than those of the handler that created the error.
 func (h *ErrorHandler) Stacktrace(err error) []Frame {
-	rErr := &rError{}
-	if errors.As(err, &rErr) {
-		return rErr.stacktrace
+	var rErr *rError
+	for _, e := range rErrors(err) {
+		if !e.hasStacktrace() {
+			continue
+		}
+		rErr = e
+		if !h.innermostStacktrace {
+			break
+		}
+	}
+	if rErr == nil {
+		return nil
+	}
+	return h.classify(rErr.frames())
+}
+
+// Stacktraces extracts the stacktraces of every rogerr layer in the given
+// error chain that has one, ordered from the outermost to the innermost.
+func (h *ErrorHandler) Stacktraces(err error) [][]Frame {
+	stacktraces := [][]Frame{}
+	for _, e := range rErrors(err) {
+		if e.hasStacktrace() {
+			stacktraces = append(stacktraces, h.classify(e.frames()))
+		}
 	}
-	return nil
+	return stacktraces
+}
+
+// classify returns a copy of the given frames classified according to this
+// handler's in-app rules, or the given frames if it has no such rules.
+func (h *ErrorHandler) classify(stacktrace []Frame) []Frame {
+	if !h.inApp.configured() || stacktrace == nil {
+		return stacktrace
+	}
+	isInApp := h.inApp.classifier()
+	frames := make([]Frame, len(stacktrace))
+	for i, f := range stacktrace {
+		f.InApp = isInApp(f.Function)
+		frames[i] = f
+	}
+	return frames
+}
+
+// Metadata extracts the metadata from every rogerr layer in the given error
+// chain, resolving conflicting keys according to the handler's MergeStrategy.
+// The metadata of each layer is redacted according to the configuration of
+// the handler that created it, and then according to this handler's.
+func (h *ErrorHandler) Metadata(err error) map[string]interface{} {
+	return h.redaction.redact(mergeMetadata(metadataLayers(err), h.merge))
 }
 
 // Wrap attaches ctx data and wraps the given error with message, optionally capturing stacktrace.
@@ -51,15 +136,15 @@ func (h *ErrorHandler) Wrap(ctx context.Context, err error, msgAndFmtArgs ...int
 	if ctx == nil && err == nil && msgAndFmtArgs == nil {
 		return nil
 	}
-	e := &rError{err: err, ctx: ctx}
+	e := &rError{err: err, ctx: ctx, handler: h}
 
 	if l := len(msgAndFmtArgs); l > 0 {
 		if msg, ok := msgAndFmtArgs[0].(string); ok {
 			e.msg = fmt.Sprintf(msg, msgAndFmtArgs[1:]...)
 		}
 	}
-	if h.stacktrace {
-		e.stacktrace = captureStacktrace(getModulePath())
+	if h.stacktrace && !(h.innermostStacktrace && hasStacktrace(err)) {
+		e.pcs, e.truncated = callers(h.stack.capacity())
 	}
 
 	return e
@@ -2,6 +2,8 @@ package rogerr
 
 import (
 	"errors"
+	"fmt"
+	"reflect"
 	"testing"
 )
 
@@ -47,7 +49,7 @@ func TestErrorHandlerWrap(t *testing.T) {
 		if rErr.msg != "wrapped error" {
 			t.Errorf("expected message 'wrapped error', got '%s'", rErr.msg)
 		}
-		if len(rErr.stacktrace) == 0 {
+		if len(rErr.frames()) == 0 {
 			t.Error("expected stacktrace to be captured when enabled")
 		}
 	})
@@ -60,7 +62,7 @@ func TestErrorHandlerWrap(t *testing.T) {
 		}
 
 		rErr := err.(*rError)
-		if len(rErr.stacktrace) != 0 {
+		if len(rErr.frames()) != 0 {
 			t.Error("expected no stacktrace when disabled")
 		}
 	})
@@ -106,3 +108,103 @@ func TestErrorHandlerStacktrace(t *testing.T) {
 		}
 	})
 }
+
+func TestErrorHandlerMetadata(t *testing.T) {
+	inner := NewErrorHandler().Wrap(WithMetadata(t.Context(), map[string]interface{}{"id": 1, "inner": true}), nil, "inner")
+	outer := NewErrorHandler().Wrap(WithMetadata(t.Context(), map[string]interface{}{"id": 2, "outer": true}), inner, "outer")
+
+	for name, tc := range map[string]struct {
+		strategy MergeStrategy
+		expected map[string]interface{}
+	}{
+		"outermost wins": {MergeOutermostWins, map[string]interface{}{"id": 2, "inner": true, "outer": true}},
+		"innermost wins": {MergeInnermostWins, map[string]interface{}{"id": 1, "inner": true, "outer": true}},
+		"keep all":       {MergeKeepAll, map[string]interface{}{"id": []interface{}{2, 1}, "inner": true, "outer": true}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got := NewErrorHandler(WithMergeStrategy(tc.strategy)).Metadata(outer); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected %v but got %v", tc.expected, got)
+			}
+		})
+	}
+
+	t.Run("keep all does not duplicate equal values", func(t *testing.T) {
+		ctx := WithMetadatum(t.Context(), "id", 1)
+		err := NewErrorHandler().Wrap(ctx, NewErrorHandler().Wrap(ctx, nil, "inner"), "outer")
+		if got := NewErrorHandler(WithMergeStrategy(MergeKeepAll)).Metadata(err)["id"]; got != 1 {
+			t.Errorf("expected a single value but got %v", got)
+		}
+	})
+}
+
+func TestInnermostStacktrace(t *testing.T) {
+	ctx := t.Context()
+	wrapThrice := func(h *ErrorHandler) error {
+		inner := h.Wrap(ctx, errors.New("low level"), "inner")
+		return h.Wrap(ctx, fmt.Errorf("fmt: %w", h.Wrap(ctx, inner, "middle")), "outer")
+	}
+
+	t.Run("captures every layer by default", func(t *testing.T) {
+		h := NewErrorHandler()
+		err := wrapThrice(h)
+		if got := len(h.Stacktraces(err)); got != 3 {
+			t.Errorf("expected 3 stacktraces but got %d", got)
+		}
+		if !reflect.DeepEqual(h.Stacktrace(err), h.Stacktraces(err)[0]) {
+			t.Error("expected Stacktrace to return the outermost stacktrace")
+		}
+	})
+
+	t.Run("captures only the innermost layer when enabled", func(t *testing.T) {
+		h := NewErrorHandler(WithInnermostStacktrace(true))
+		err := wrapThrice(h)
+		stacktraces := h.Stacktraces(err)
+		if len(stacktraces) != 1 {
+			t.Fatalf("expected 1 stacktrace but got %d", len(stacktraces))
+		}
+		if !reflect.DeepEqual(h.Stacktrace(err), stacktraces[0]) {
+			t.Error("expected Stacktrace to return the only stacktrace")
+		}
+	})
+
+	t.Run("returns the innermost stacktrace when enabled", func(t *testing.T) {
+		err := wrapThrice(NewErrorHandler())
+		innermost := NewErrorHandler().Stacktraces(err)[2]
+		if got := NewErrorHandler(WithInnermostStacktrace(true)).Stacktrace(err); !reflect.DeepEqual(got, innermost) {
+			t.Errorf("expected the innermost stacktrace but got %+v", got)
+		}
+	})
+
+	t.Run("captures when the cause has no stacktrace", func(t *testing.T) {
+		h := NewErrorHandler(WithInnermostStacktrace(true))
+		inner := NewErrorHandler(WithStacktrace(false)).Wrap(ctx, nil, "inner")
+		if got := len(h.Stacktrace(h.Wrap(ctx, inner, "outer"))); got == 0 {
+			t.Error("expected a stacktrace to be captured")
+		}
+	})
+
+	t.Run("other handlers find the captured stacktrace", func(t *testing.T) {
+		err := wrapThrice(NewErrorHandler(WithInnermostStacktrace(true)))
+		if got := NewErrorHandler().Stacktrace(err); len(got) == 0 {
+			t.Error("expected the stacktrace of the innermost layer to be returned")
+		}
+	})
+
+	t.Run("logs the captured stacktrace", func(t *testing.T) {
+		h := NewErrorHandler(WithInnermostStacktrace(true), WithLogStacktrace(true), WithInAppPackages("testing"))
+		err := wrapThrice(h).(*rError)
+		for _, attr := range err.LogValue().Group() {
+			if attr.Key == "stacktrace" {
+				return
+			}
+		}
+		t.Error("expected the stacktrace of the innermost layer to be logged")
+	})
+
+	t.Run("non-rogerr errors", func(t *testing.T) {
+		h := NewErrorHandler(WithInnermostStacktrace(true))
+		if h.Stacktrace(errors.New("plain")) != nil || len(h.Stacktraces(errors.New("plain"))) != 0 {
+			t.Error("expected no stacktraces for non-rogerr errors")
+		}
+	})
+}
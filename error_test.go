@@ -1,8 +1,12 @@
 package rogerr
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"log/slog"
 	"testing"
 )
 
@@ -94,3 +98,80 @@ func TestWrap(t *testing.T) {
 	}
 }
 
+func TestLogValue(t *testing.T) {
+	logged := func(t *testing.T, err error) map[string]interface{} {
+		t.Helper()
+		var buf bytes.Buffer
+		slog.New(slog.NewJSONHandler(&buf, nil)).Error("failed", slog.Any("err", err))
+		got := map[string]interface{}{}
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatalf("unable to unmarshal log output %s: %v", buf.String(), err)
+		}
+		group, ok := got["err"].(map[string]interface{})
+		if !ok {
+			t.Fatalf("expected err to be logged as a group but got %v", got["err"])
+		}
+		return group
+	}
+
+	ctx := WithMetadatum(context.Background(), "userID", 123)
+	inner := NewErrorHandler().Wrap(ctx, errors.New("low level"), "inner")
+	wrapped := fmt.Errorf("fmt: %w", inner)
+
+	t.Run("message, metadata and causes", func(t *testing.T) {
+		got := logged(t, NewErrorHandler().Wrap(WithMetadatum(ctx, "requestID", "abc"), wrapped, "outer"))
+
+		if exp := "outer: fmt: inner: low level"; got["message"] != exp {
+			t.Errorf("expected message %q but got %q", exp, got["message"])
+		}
+		md, ok := got["metadata"].(map[string]interface{})
+		if !ok || md["userID"] != float64(123) || md["requestID"] != "abc" {
+			t.Errorf("unexpected metadata %v", got["metadata"])
+		}
+		if exp, got := "[fmt inner low level]", fmt.Sprint(got["causes"]); got != exp {
+			t.Errorf("expected causes %s but got %s", exp, got)
+		}
+		if _, ok := got["stacktrace"]; ok {
+			t.Errorf("expected no stacktrace by default but got %v", got["stacktrace"])
+		}
+	})
+
+	t.Run("causes include joined errors", func(t *testing.T) {
+		got := logged(t, NewErrorHandler().Wrap(ctx, errors.Join(inner, errors.New("other")), "outer"))
+		if exp, got := "[inner low level other]", fmt.Sprint(got["causes"]); got != exp {
+			t.Errorf("expected causes %s but got %s", exp, got)
+		}
+	})
+
+	t.Run("stacktrace is included when enabled", func(t *testing.T) {
+		err := &rError{msg: "oops", handler: NewErrorHandler(WithLogStacktrace(true)), stacktrace: []Frame{
+			{File: "/app/main.go", Line: 12, Function: "main.main", InApp: true},
+			{File: "/go/src/runtime/proc.go", Line: 283, Function: "runtime.main", InApp: false},
+		}}
+		if exp, got := "[main.main (/app/main.go:12)]", fmt.Sprint(logged(t, err)["stacktrace"]); got != exp {
+			t.Errorf("expected in-app stacktrace %s but got %s", exp, got)
+		}
+	})
+}
+
+func TestRootCause(t *testing.T) {
+	root := errors.New("root")
+	joined := errors.Join(root, errors.New("other"))
+	h := NewErrorHandler(WithStacktrace(false))
+	rErr := h.Wrap(context.Background(), nil, "oops")
+	for name, tc := range map[string]struct {
+		err, exp error
+	}{
+		"nil":            {err: nil, exp: nil},
+		"unwrapped":      {err: root, exp: root},
+		"wrapped":        {err: fmt.Errorf("fmt: %w", h.Wrap(nil, root, "wrapped")), exp: root},
+		"joined":         {err: fmt.Errorf("fmt: %w", joined), exp: joined},
+		"rogerr as root": {err: fmt.Errorf("fmt: %w", rErr), exp: rErr},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got := RootCause(tc.err); got != tc.exp { //nolint:errorlint // testing identity
+				t.Errorf("expected %v but got %v", tc.exp, got)
+			}
+		})
+	}
+}
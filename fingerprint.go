@@ -0,0 +1,45 @@
+package rogerr
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+)
+
+var (
+	uuidPattern   = regexp.MustCompile(`(?i)[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`)
+	numberPattern = regexp.MustCompile(`[0-9]+`)
+)
+
+// Fingerprint returns a key for grouping errors that come from the same code path.
+// The fingerprint is derived from the message of every layer in the error
+// chain and the function names of the in-app frames of the innermost
+// stacktrace. Metadata and line numbers are ignored, and UUIDs and numbers
+// that have slipped into error messages are normalised, so the fingerprint
+// is stable across hosts and builds.
+// Returns an empty string for a nil error.
+func Fingerprint(err error) string {
+	if err == nil {
+		return ""
+	}
+	h := sha256.New()
+	var stacktrace []Frame
+	walkLayers(err, func(layer error) {
+		fmt.Fprintf(h, "%s\x00", normaliseMessage(layerMessage(layer)))
+		if rErr, ok := layer.(*rError); ok && len(rErr.frames()) > 0 {
+			stacktrace = rErr.frames()
+		}
+	})
+	for _, f := range stacktrace {
+		if f.InApp {
+			fmt.Fprintf(h, "%s\x00", f.Function)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+func normaliseMessage(msg string) string {
+	msg = uuidPattern.ReplaceAllString(msg, "<uuid>")
+	return numberPattern.ReplaceAllString(msg, "<n>")
+}
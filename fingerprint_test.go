@@ -0,0 +1,65 @@
+package rogerr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	frames := func(line int, function string) []Frame {
+		return []Frame{
+			{File: "/app/main.go", Line: line, Function: function, InApp: true},
+			{File: "/go/src/runtime/proc.go", Line: line, Function: "runtime.main", InApp: false},
+		}
+	}
+	newErr := func(ctx context.Context, cause error, msg string, stacktrace []Frame) error {
+		return fmt.Errorf("fmt: %w", &rError{ctx: ctx, err: cause, msg: msg, stacktrace: stacktrace})
+	}
+	base := newErr(context.Background(), errors.New("low level"), "user 123 not found", frames(12, "main.main"))
+
+	t.Run("stable for the same code path", func(t *testing.T) {
+		for name, err := range map[string]error{
+			"different metadata":     newErr(WithMetadatum(context.Background(), "userID", 123), errors.New("low level"), "user 123 not found", frames(12, "main.main")),
+			"different line numbers": newErr(context.Background(), errors.New("low level"), "user 123 not found", frames(34, "main.main")),
+			"different numbers":      newErr(context.Background(), errors.New("low level"), "user 456 not found", frames(12, "main.main")),
+			"different dependencies": newErr(context.Background(), errors.New("low level"), "user 123 not found", frames(12, "main.main")[:1]),
+		} {
+			t.Run(name, func(t *testing.T) {
+				if exp, got := Fingerprint(base), Fingerprint(err); exp != got {
+					t.Errorf("expected fingerprint %s but got %s", exp, got)
+				}
+			})
+		}
+	})
+
+	t.Run("differs for different code paths", func(t *testing.T) {
+		for name, err := range map[string]error{
+			"different message":  newErr(context.Background(), errors.New("low level"), "user 123 disabled", frames(12, "main.main")),
+			"different cause":    newErr(context.Background(), errors.New("other"), "user 123 not found", frames(12, "main.main")),
+			"different function": newErr(context.Background(), errors.New("low level"), "user 123 not found", frames(12, "main.run")),
+			"no stacktrace":      newErr(context.Background(), errors.New("low level"), "user 123 not found", nil),
+		} {
+			t.Run(name, func(t *testing.T) {
+				if Fingerprint(base) == Fingerprint(err) {
+					t.Errorf("expected fingerprints to differ but both were %s", Fingerprint(err))
+				}
+			})
+		}
+	})
+
+	t.Run("UUIDs are normalised", func(t *testing.T) {
+		a := errors.New("job 0f8fad5b-d9cb-469f-a165-70867728950e failed")
+		b := errors.New("job 7C9E6679-7425-40DE-944B-E07FC1F90AE7 failed")
+		if Fingerprint(a) != Fingerprint(b) {
+			t.Error("expected UUIDs to be normalised")
+		}
+	})
+
+	t.Run("nil error", func(t *testing.T) {
+		if got := Fingerprint(nil); got != "" {
+			t.Errorf("expected empty fingerprint but got %s", got)
+		}
+	})
+}
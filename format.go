@@ -0,0 +1,69 @@
+package rogerr
+
+import (
+	"fmt"
+	"io"
+)
+
+// Format implements fmt.Formatter.
+// The %s and %v verbs print the error message, and %q prints it quoted.
+// The %+v verb prints the error message followed by every layer of the error
+// chain, including any errors wrapped with fmt.Errorf's %w verb, along with
+// each rogerr layer's metadata and stacktrace.
+func (e *rError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		fmt.Fprint(s, e.Error())
+		if s.Flag('+') {
+			writeVerbose(s, e.errorHandler(), e)
+		}
+	case 's':
+		fmt.Fprint(s, e.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", e.Error())
+	default:
+		fmt.Fprintf(s, "%%!%c(%s)", verb, e.Error())
+	}
+}
+
+// writeVerbose writes a block for the given error and every error it wraps.
+// Blocks after the first are introduced with "caused by". Metadata is
+// redacted according to the given handler.
+func writeVerbose(w io.Writer, h *ErrorHandler, err error) {
+	prefix := ""
+	walkLayers(err, func(layer error) {
+		fmt.Fprintf(w, "\n%s%s", prefix, layerMessage(layer))
+		if rErr, ok := layer.(*rError); ok {
+			writeMetadata(w, h.redaction.redact(rErr.metadata()))
+			writeStacktrace(w, rErr.frames())
+		}
+		prefix = "caused by: "
+	})
+}
+
+func writeMetadata(w io.Writer, md map[string]interface{}) {
+	if len(md) == 0 {
+		return
+	}
+	fmt.Fprint(w, "\n    metadata:")
+	for k, v := range NewMetadataSet(md).All() {
+		fmt.Fprintf(w, "\n        %s: %v", k, v)
+	}
+}
+
+func writeStacktrace(w io.Writer, frames []Frame) {
+	if len(frames) == 0 {
+		return
+	}
+	fmt.Fprint(w, "\n    stacktrace:")
+	for _, f := range frames {
+		marker := "[dep]"
+		if f.InApp {
+			marker = "[app]"
+		}
+		fmt.Fprintf(w, "\n        %s %s\n            %s:%d", marker, f.Function, f.File, f.Line)
+		if f.Truncated {
+			fmt.Fprint(w, "\n        ...")
+		}
+	}
+}
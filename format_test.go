@@ -0,0 +1,64 @@
+package rogerr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	ctx := WithMetadatum(context.Background(), "userID", 123)
+	inner := &rError{ctx: ctx, err: errors.New("low level"), msg: "inner", stacktrace: []Frame{
+		{File: "/app/main.go", Line: 12, Function: "main.main", InApp: true},
+		{File: "/go/src/runtime/proc.go", Line: 283, Function: "runtime.main", InApp: false},
+	}}
+	err := &rError{ctx: WithMetadatum(ctx, "requestID", "abc"), err: fmt.Errorf("fmt: %w", inner), msg: "outer"}
+
+	for _, tc := range []struct {
+		format string
+		exp    string
+	}{
+		{format: "%s", exp: "outer: fmt: inner: low level"},
+		{format: "%v", exp: "outer: fmt: inner: low level"},
+		{format: "%q", exp: `"outer: fmt: inner: low level"`},
+		{format: "%d", exp: "%!d(outer: fmt: inner: low level)"},
+		{format: "%+v", exp: `outer: fmt: inner: low level
+outer
+    metadata:
+        requestID: abc
+        userID: 123
+caused by: fmt
+caused by: inner
+    metadata:
+        userID: 123
+    stacktrace:
+        [app] main.main
+            /app/main.go:12
+        [dep] runtime.main
+            /go/src/runtime/proc.go:283
+caused by: low level`},
+	} {
+		t.Run(tc.format, func(t *testing.T) {
+			if got := fmt.Sprintf(tc.format, err); got != tc.exp {
+				t.Errorf("expected\n%s\nbut got\n%s", tc.exp, got)
+			}
+		})
+	}
+
+	t.Run("truncated stacktrace", func(t *testing.T) {
+		truncated := &rError{msg: "oops", stacktrace: []Frame{{File: "/app/main.go", Line: 12, Function: "main.main", InApp: true, Truncated: true}}}
+		exp := "oops\noops\n    stacktrace:\n        [app] main.main\n            /app/main.go:12\n        ..."
+		if got := fmt.Sprintf("%+v", truncated); got != exp {
+			t.Errorf("expected\n%s\nbut got\n%s", exp, got)
+		}
+	})
+
+	t.Run("joined errors", func(t *testing.T) {
+		joined := &rError{msg: "outer", err: errors.Join(errors.New("first"), errors.New("second"))}
+		exp := "outer: first\nsecond\nouter\ncaused by: first\ncaused by: second"
+		if got := fmt.Sprintf("%+v", joined); got != exp {
+			t.Errorf("expected\n%s\nbut got\n%s", exp, got)
+		}
+	})
+}
@@ -0,0 +1,189 @@
+/*
+Package httperr provides net/http middleware for rogerr.
+
+The middleware attaches request metadata to each request's context, converts
+panics into rogerr errors, and reports failed requests:
+
+	mw := httperr.New(httperr.WithReporter(reporter))
+	mux.Handle("GET /users/{id}", mw.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		...
+	}))
+*/
+package httperr
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/kinbiko/rogerr"
+)
+
+// Reporter receives the errors of failed requests, e.g. a rogerr.ErrorHandler
+// configured with sinks.
+type Reporter = rogerr.Reporter
+
+// ReporterFunc is an adapter that allows ordinary functions to be used as Reporters.
+type ReporterFunc = rogerr.ReportFunc
+
+// ErrorHandlerFunc is an HTTP handler that returns an error if the request failed.
+type ErrorHandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// Middleware attaches request metadata to contexts and reports failed requests.
+type Middleware struct {
+	errors   *rogerr.ErrorHandler
+	reporter Reporter
+	headers  map[string]string
+
+	// stackless wraps errors that already have a stacktrace, so that the
+	// stacktrace of the middleware doesn't take precedence over theirs.
+	stackless *rogerr.ErrorHandler
+}
+
+// Option is a function that configures a Middleware.
+type Option func(*Middleware)
+
+// WithErrorHandler configures the rogerr.ErrorHandler used to wrap errors and recover panics.
+func WithErrorHandler(errorHandler *rogerr.ErrorHandler) Option {
+	return func(m *Middleware) {
+		m.errors = errorHandler
+	}
+}
+
+// WithReporter configures the Reporter that receives the errors of failed requests.
+func WithReporter(reporter Reporter) Option {
+	return func(m *Middleware) {
+		m.reporter = reporter
+	}
+}
+
+// WithHeader configures the value of the given request header to be
+// attached to the request's context under the given metadata key.
+func WithHeader(header, key string) Option {
+	return func(m *Middleware) {
+		m.headers[header] = key
+	}
+}
+
+// New creates a new Middleware with the given options.
+// By default, the X-Request-Id header is attached as "http.request.id", and
+// failed requests are not reported anywhere.
+func New(opts ...Option) *Middleware {
+	m := &Middleware{
+		errors:   rogerr.NewErrorHandler(),
+		reporter: ReporterFunc(func(context.Context, error) {}),
+		headers:  map[string]string{"X-Request-Id": "http.request.id"},
+
+		stackless: rogerr.NewErrorHandler(rogerr.WithStacktrace(false)),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Handler attaches the request's method, route, path, remote address, and
+// configured headers to its context as metadata before calling next.
+// If next panics, the panic is reported and a 500 response is written.
+//
+// The route is only known once an http.ServeMux has matched the request, so
+// it isn't attached when Handler wraps the mux itself, e.g. Handler(mux).
+// Register Handler per route instead, or use HandlerFunc, which attaches the
+// route from within the mux.
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := m.requestContext(r)
+		rw := &responseWriter{ResponseWriter: w}
+		var err error
+		defer func() {
+			if err == nil {
+				return
+			}
+			if errors.Is(err, http.ErrAbortHandler) {
+				panic(http.ErrAbortHandler)
+			}
+			m.fail(ctx, rw, err)
+		}()
+		defer m.errors.Recover(ctx, &err)
+		next.ServeHTTP(rw, r.WithContext(ctx))
+	})
+}
+
+// HandlerFunc adapts the given ErrorHandlerFunc into an http.Handler with
+// the same behaviour as Handler. If fn returns an error, it is wrapped with
+// the request's metadata and reported. A stacktrace is only captured when
+// wrapping if the error doesn't have one already. Unless fn already wrote a response,
+// a response is written with the HTTP status of the error's rogerr.Code,
+// which is 500 for errors without a code.
+func (m *Middleware) HandlerFunc(fn ErrorHandlerFunc) http.Handler {
+	return m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Pattern != "" && !m.hasRoute(r.Context()) {
+			r = r.WithContext(rogerr.WithMetadatum(r.Context(), "http.route", r.Pattern))
+		}
+		if err := fn(w, r); err != nil {
+			m.fail(r.Context(), w.(*responseWriter), m.wrap(r.Context(), err)) //nolint:errcheck // Handler always passes a *responseWriter
+		}
+	}))
+}
+
+// hasRoute reports whether the route is already attached to the given
+// context, without resolving the values of any other metadata.
+func (m *Middleware) hasRoute(ctx context.Context) bool {
+	_, ok := m.errors.SelectContextMetadata(ctx, func(k string) bool { return k == "http.route" })["http.route"]
+	return ok
+}
+
+// wrap wraps the given error with the metadata of the given context,
+// capturing a stacktrace only if the error doesn't have one already.
+func (m *Middleware) wrap(ctx context.Context, err error) error {
+	if len(m.errors.Stacktraces(err)) > 0 {
+		return m.stackless.Wrap(ctx, err)
+	}
+	return m.errors.Wrap(ctx, err)
+}
+
+func (m *Middleware) requestContext(r *http.Request) context.Context {
+	md := map[string]interface{}{
+		"http.request.method": r.Method,
+		"url.path":            r.URL.Path,
+		"client.address":      r.RemoteAddr,
+	}
+	if r.Pattern != "" {
+		md["http.route"] = r.Pattern
+	}
+	for header, key := range m.headers {
+		if v := r.Header.Get(header); v != "" {
+			md[key] = v
+		}
+	}
+	return rogerr.WithMetadata(r.Context(), md)
+}
+
+func (m *Middleware) fail(ctx context.Context, w *responseWriter, err error) {
+	m.reporter.Report(ctx, err)
+	if !w.wroteHeader {
+		status := rogerr.CodeOf(err).HTTPStatus()
+		http.Error(w, http.StatusText(status), status)
+	}
+}
+
+// responseWriter records whether a response has been written.
+type responseWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(statusCode int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap allows http.ResponseController to access the underlying ResponseWriter.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,198 @@
+package httperr_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kinbiko/rogerr"
+	"github.com/kinbiko/rogerr/httperr"
+)
+
+type reported struct {
+	err      error
+	metadata map[string]interface{}
+}
+
+func newMiddleware(opts ...httperr.Option) (*httperr.Middleware, *[]reported) {
+	reports := &[]reported{}
+	reporter := httperr.ReporterFunc(func(_ context.Context, err error) {
+		*reports = append(*reports, reported{err: err, metadata: rogerr.Metadata(err)})
+	})
+	return httperr.New(append([]httperr.Option{httperr.WithReporter(reporter)}, opts...)...), reports
+}
+
+func findUser(ctx context.Context) error {
+	return rogerr.NewErrorHandler().Wrap(ctx, errors.New("db down"), "finding user")
+}
+
+func serve(h http.Handler, pattern string) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	mux.Handle(pattern, h)
+	req := httptest.NewRequest(http.MethodGet, "/users/123", nil)
+	req.Header.Set("X-Request-Id", "req-1")
+	req.Header.Set("X-Tenant", "acme")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandlerFunc(t *testing.T) {
+	t.Run("returned errors are reported with request metadata", func(t *testing.T) {
+		mw, reports := newMiddleware(httperr.WithHeader("X-Tenant", "tenant"))
+		cause := errors.New("db down")
+		rec := serve(mw.HandlerFunc(func(http.ResponseWriter, *http.Request) error { return cause }), "GET /users/{id}")
+
+		if rec.Code != http.StatusInternalServerError {
+			t.Errorf("expected status 500 but got %d", rec.Code)
+		}
+		if len(*reports) != 1 {
+			t.Fatalf("expected 1 report but got %d", len(*reports))
+		}
+		got := (*reports)[0]
+		if !errors.Is(got.err, cause) {
+			t.Errorf("expected reported error to wrap %v but got %v", cause, got.err)
+		}
+		for k, v := range map[string]interface{}{
+			"http.request.method": http.MethodGet,
+			"http.route":          "GET /users/{id}",
+			"url.path":            "/users/123",
+			"client.address":      "192.0.2.1:1234",
+			"http.request.id":     "req-1",
+			"tenant":              "acme",
+		} {
+			if got.metadata[k] != v {
+				t.Errorf("expected metadata at key '%s' to be <%v> but was <%v>", k, v, got.metadata[k])
+			}
+		}
+		if len(rogerr.NewErrorHandler().Stacktrace(got.err)) == 0 {
+			t.Error("expected reported error to have a stacktrace")
+		}
+	})
+
+	t.Run("stacktraces of returned errors are kept", func(t *testing.T) {
+		mw, reports := newMiddleware()
+		serve(mw.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) error { return findUser(r.Context()) }), "GET /users/{id}")
+
+		if len(*reports) != 1 {
+			t.Fatalf("expected 1 report but got %d", len(*reports))
+		}
+		for _, f := range rogerr.NewErrorHandler().Stacktrace((*reports)[0].err) {
+			if f.Function == "github.com/kinbiko/rogerr/httperr_test.findUser" {
+				return
+			}
+		}
+		t.Errorf("expected the stacktrace to include findUser but got %+v", rogerr.NewErrorHandler().Stacktrace((*reports)[0].err))
+	})
+
+	t.Run("lazy values are not computed for successful requests", func(t *testing.T) {
+		mw, _ := newMiddleware()
+		ctx := rogerr.WithMetadatum(context.Background(), "db.stats", rogerr.Lazy(func() any {
+			t.Error("expected the lazy value not to be computed")
+			return nil
+		}))
+		mux := http.NewServeMux()
+		mux.Handle("GET /users/{id}", mw.HandlerFunc(func(http.ResponseWriter, *http.Request) error { return nil }))
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/123", nil).WithContext(ctx))
+	})
+
+	t.Run("route is attached when the middleware wraps the mux", func(t *testing.T) {
+		mw, reports := newMiddleware()
+		mux := http.NewServeMux()
+		mux.Handle("GET /users/{id}", mw.HandlerFunc(func(http.ResponseWriter, *http.Request) error {
+			return errors.New("db down")
+		}))
+		mw.Handler(mux).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/123", nil))
+
+		if len(*reports) != 1 {
+			t.Fatalf("expected 1 report but got %d", len(*reports))
+		}
+		if got := (*reports)[0].metadata["http.route"]; got != "GET /users/{id}" {
+			t.Errorf("expected http.route metadata but got %v", got)
+		}
+	})
+
+	t.Run("response status follows the error code", func(t *testing.T) {
+		mw, _ := newMiddleware()
+		rec := serve(mw.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) error {
+			return rogerr.Wrap(rogerr.WithCode(r.Context(), rogerr.CodeNotFound), nil, "user not found")
+		}), "/")
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("expected status 404 but got %d", rec.Code)
+		}
+	})
+
+	t.Run("responses already written are kept", func(t *testing.T) {
+		mw, reports := newMiddleware()
+		rec := serve(mw.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) error {
+			w.WriteHeader(http.StatusBadGateway)
+			return errors.New("upstream failed")
+		}), "/")
+		if rec.Code != http.StatusBadGateway {
+			t.Errorf("expected status 502 but got %d", rec.Code)
+		}
+		if len(*reports) != 1 {
+			t.Errorf("expected 1 report but got %d", len(*reports))
+		}
+	})
+
+	t.Run("successful requests are not reported", func(t *testing.T) {
+		mw, reports := newMiddleware()
+		rec := serve(mw.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			if got := rogerr.ContextMetadata(r.Context())["http.request.id"]; got != "req-1" {
+				t.Errorf("expected request metadata in handler context but got %v", got)
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return nil
+		}), "/")
+		if rec.Code != http.StatusNoContent || len(*reports) != 0 {
+			t.Errorf("unexpected status %d and reports %v", rec.Code, *reports)
+		}
+	})
+}
+
+func TestHandler(t *testing.T) {
+	t.Run("panics are recovered into 500 responses", func(t *testing.T) {
+		mw, reports := newMiddleware()
+		rec := serve(mw.Handler(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			panic("oops")
+		})), "/")
+
+		if rec.Code != http.StatusInternalServerError {
+			t.Errorf("expected status 500 but got %d", rec.Code)
+		}
+		if len(*reports) != 1 {
+			t.Fatalf("expected 1 report but got %d", len(*reports))
+		}
+		got := (*reports)[0]
+		if got.err.Error() != "panic: oops" || got.metadata["panic.type"] != "string" || got.metadata["url.path"] != "/users/123" {
+			t.Errorf("unexpected report %+v", got)
+		}
+	})
+
+	t.Run("http.ErrAbortHandler is re-panicked", func(t *testing.T) {
+		mw, reports := newMiddleware()
+		defer func() {
+			if r := recover(); r != http.ErrAbortHandler {
+				t.Errorf("expected http.ErrAbortHandler panic but got %v", r)
+			}
+			if len(*reports) != 0 {
+				t.Errorf("expected no reports but got %v", *reports)
+			}
+		}()
+		serve(mw.Handler(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			panic(http.ErrAbortHandler)
+		})), "/")
+	})
+
+	t.Run("default reporter", func(t *testing.T) {
+		rec := serve(httperr.New().HandlerFunc(func(http.ResponseWriter, *http.Request) error {
+			return errors.New("oops")
+		}), "/")
+		if rec.Code != http.StatusInternalServerError {
+			t.Errorf("expected status 500 but got %d", rec.Code)
+		}
+	})
+}
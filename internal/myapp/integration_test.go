@@ -48,3 +48,24 @@ func TestStacktraceIntegration(t *testing.T) {
 		t.Errorf("expected 0 frames from rogerr module, got %d", rogerrFrames)
 	}
 }
+
+func TestReplacedModulesInAppIntegration(t *testing.T) {
+	err := run([]string{"testdata"})
+	if err == nil {
+		t.Fatal("expected error from run")
+	}
+
+	frames := rogerr.NewErrorHandler(rogerr.WithReplacedModulesInApp(true)).Stacktrace(err)
+	var mylibFrames int
+	for _, frame := range frames {
+		if strings.Contains(frame.File, "/internal/mylib/") {
+			mylibFrames++
+			if !frame.InApp {
+				t.Errorf("mylib frame should be InApp=true when replaced modules are in-app: %s", frame.Function)
+			}
+		}
+	}
+	if mylibFrames != 5 {
+		t.Errorf("expected 5 frames from mylib module, got %d", mylibFrames)
+	}
+}
@@ -7,10 +7,11 @@ import (
 
 	"github.com/kinbiko/rogerr"
 	"github.com/kinbiko/rogerr/internal/myapp/cmd"
+	"github.com/kinbiko/rogerr/slogerr"
 )
 
 func main() {
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	logger := slog.New(slogerr.NewHandler(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo})))
 	slog.SetDefault(logger)
 
 	args := os.Args[1:] // Skip program name
@@ -23,32 +24,17 @@ func main() {
 		return
 	}
 
-	frames := rogerr.NewErrorHandler().Stacktrace(err)
-
-	// Convert frames to OTEL logging data model format
-	frameData := make([]map[string]interface{}, len(frames))
-	for i, frame := range frames {
-		frameData[i] = map[string]interface{}{
-			"code.function": frame.Function,
-			"code.filepath": frame.File,
-			"code.lineno":   frame.Line,
-			"code.namespace": func() string {
-				if frame.InApp {
-					return "application"
-				}
-				return "dependency"
-			}(),
-		}
-	}
-
-	// Log using OTEL logging data model semantic conventions
-	slog.Error("Exception occurred",
-		slog.String("exception.type", "ApplicationError"),
-		slog.String("exception.message", err.Error()),
-		slog.Any("exception.stacktrace", frameData),
-		slog.String("service.name", "demo-app"),
-		slog.String("service.version", "1.0.0"),
-	)
+	// The sink logs the reported error through the slogerr handler set up
+	// above, which expands it into OTEL logging data model attributes
+	reporter := rogerr.NewErrorHandler(rogerr.WithSinks(rogerr.SinkFunc(func(ctx context.Context, event *rogerr.Event) error {
+		slog.ErrorContext(ctx, "Exception occurred", slog.Any("error", event.Err))
+		return nil
+	})))
+	ctx := rogerr.WithMetadata(context.Background(), map[string]interface{}{
+		"service.name":    "demo-app",
+		"service.version": "1.0.0",
+	})
+	reporter.Report(ctx, err)
 }
 
 func run(args []string) error {
@@ -0,0 +1,177 @@
+package rogerr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// rErrorType is the type name of rogerr layers, both in the JSON
+// representation of an error and as reported by ErrorType.
+const rErrorType = "rogerr.Error"
+
+// errorJSON is the JSON representation of a single layer of an error chain.
+type errorJSON struct {
+	Message     string                 `json:"message"`
+	Type        string                 `json:"type"`
+	WrapMessage string                 `json:"wrapMessage,omitempty"`
+	Code        *Code                  `json:"code,omitempty"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	Stacktrace  []Frame                `json:"stacktrace,omitempty"`
+	Breadcrumbs []BreadcrumbEntry      `json:"breadcrumbs,omitempty"`
+	Cause       *errorJSON             `json:"cause,omitempty"`
+	Causes      []*errorJSON           `json:"causes,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+// The error's message, code, metadata, and stacktrace are included, along with
+// every error in its chain. Metadata values that cannot be marshalled are
+// represented by their %v string instead.
+func (e *rError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(toErrorJSON(e, e.errorHandler()))
+}
+
+// UnmarshalError rebuilds an error from JSON created by marshalling a rogerr error.
+// Metadata and ErrorHandler.Stacktrace work on the returned error as they
+// did on the original, although metadata values will have the types that
+// encoding/json gives them, e.g. float64 for numbers.
+// Non-rogerr errors in the chain are rebuilt as errors with the same
+// message that wrap the rest of the chain.
+func UnmarshalError(data []byte) (error, error) {
+	var j *errorJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return nil, err
+	}
+	return fromErrorJSON(j), nil
+}
+
+// toErrorJSON converts the given error chain into its JSON representation,
+// redacting metadata and breadcrumbs according to the given handler.
+func toErrorJSON(err error, h *ErrorHandler) *errorJSON {
+	j := &errorJSON{Message: err.Error(), Type: fmt.Sprintf("%T", err)}
+	switch e := err.(type) {
+	case *rError:
+		j.Type = rErrorType
+		j.WrapMessage = e.msg
+		if code, ok := e.code(); ok {
+			j.Code = &code
+		}
+		j.Metadata = JSONSafe(h.redaction.redact(e.metadata()))
+		j.Stacktrace = e.frames()
+		if e.ctx != nil {
+			j.Breadcrumbs = jsonSafeBreadcrumbs(h.breadcrumbs(getCtxData(e.ctx).breadcrumbs, map[*breadcrumbNode]bool{}, &e.errorHandler().redaction))
+		}
+	case *remoteError:
+		j.Type = e.typ
+	case *remoteJoinError:
+		j.Type = e.typ
+	}
+
+	switch u := err.(type) {
+	case interface{ Unwrap() error }:
+		if cause := u.Unwrap(); cause != nil {
+			j.Cause = toErrorJSON(cause, h)
+		}
+	case interface{ Unwrap() []error }:
+		for _, cause := range u.Unwrap() {
+			j.Causes = append(j.Causes, toErrorJSON(cause, h))
+		}
+	}
+	return j
+}
+
+func fromErrorJSON(j *errorJSON) error {
+	if j == nil {
+		return nil
+	}
+	cause := fromErrorJSON(j.Cause)
+	switch {
+	case j.Type == rErrorType:
+		var ctx context.Context
+		if j.Metadata != nil || j.Code != nil || len(j.Breadcrumbs) > 0 {
+			ctx = WithMetadata(context.Background(), j.Metadata)
+		}
+		if j.Code != nil {
+			ctx = WithCode(ctx, *j.Code)
+		}
+		if len(j.Breadcrumbs) > 0 {
+			ctx = withBreadcrumbs(ctx, j.Breadcrumbs...)
+		}
+		return &rError{err: cause, ctx: ctx, msg: j.WrapMessage, stacktrace: j.Stacktrace}
+	case j.Causes != nil:
+		causes := make([]error, len(j.Causes))
+		for i, c := range j.Causes {
+			causes[i] = fromErrorJSON(c)
+		}
+		return &remoteJoinError{msg: j.Message, typ: j.Type, causes: causes}
+	default:
+		return &remoteError{msg: j.Message, typ: j.Type, cause: cause}
+	}
+}
+
+// JSONSafe returns a copy of the given metadata where every value that
+// cannot be marshalled to JSON is replaced with its %v representation.
+// Returns nil if the given metadata is nil.
+func JSONSafe(md map[string]interface{}) map[string]interface{} {
+	if md == nil {
+		return nil
+	}
+	safe := make(map[string]interface{}, len(md))
+	for k, v := range md {
+		if _, err := json.Marshal(v); err != nil {
+			v = fmt.Sprintf("%v", v)
+		}
+		safe[k] = v
+	}
+	return safe
+}
+
+// jsonSafeBreadcrumbs returns a copy of the given breadcrumbs whose data is
+// made safe to marshal as with JSONSafe. Returns nil if there are none.
+func jsonSafeBreadcrumbs(entries []BreadcrumbEntry) []BreadcrumbEntry {
+	if len(entries) == 0 {
+		return nil
+	}
+	safe := make([]BreadcrumbEntry, len(entries))
+	for i, entry := range entries {
+		entry.Data = JSONSafe(entry.Data)
+		safe[i] = entry
+	}
+	return safe
+}
+
+// ErrorType returns a stable name for the type of the given error, for use
+// in exported error reports, e.g. "*errors.errorString".
+// Layers created by rogerr are named "rogerr.Error", and errors rebuilt by
+// UnmarshalError keep the type name of the original error.
+func ErrorType(err error) string {
+	switch e := err.(type) {
+	case *rError:
+		return rErrorType
+	case *remoteError:
+		return e.typ
+	case *remoteJoinError:
+		return e.typ
+	}
+	return fmt.Sprintf("%T", err)
+}
+
+// remoteError is a non-rogerr error rebuilt from JSON.
+type remoteError struct {
+	msg   string
+	typ   string
+	cause error
+}
+
+func (e *remoteError) Error() string { return e.msg }
+func (e *remoteError) Unwrap() error { return e.cause }
+
+// remoteJoinError is a non-rogerr error that wrapped several errors, rebuilt from JSON.
+type remoteJoinError struct {
+	msg    string
+	typ    string
+	causes []error
+}
+
+func (e *remoteJoinError) Error() string   { return e.msg }
+func (e *remoteJoinError) Unwrap() []error { return e.causes }
@@ -0,0 +1,158 @@
+package rogerr_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/kinbiko/rogerr"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	handler := rogerr.NewErrorHandler()
+	ctx := rogerr.WithMetadata(context.Background(), map[string]interface{}{"userID": 123, "name": "kinbiko"})
+	ctx = rogerr.Breadcrumb(ctx, "app", "started", map[string]interface{}{"attempt": 1})
+	inner := handler.Wrap(ctx, errors.New("low level"), "inner")
+	original := handler.Wrap(rogerr.WithMetadatum(ctx, "requestID", "abc"), fmt.Errorf("fmt: %w", inner), "outer")
+
+	data, err := json.Marshal(original)
+	if err != nil {
+		t.Fatalf("unable to marshal error: %v", err)
+	}
+	rebuilt, err := rogerr.UnmarshalError(data)
+	if err != nil {
+		t.Fatalf("unable to unmarshal error: %v", err)
+	}
+
+	t.Run("message", func(t *testing.T) {
+		if exp, got := original.Error(), rebuilt.Error(); exp != got {
+			t.Errorf("expected message %q but got %q", exp, got)
+		}
+	})
+
+	t.Run("metadata", func(t *testing.T) {
+		exp := map[string]interface{}{"userID": float64(123), "name": "kinbiko", "requestID": "abc"}
+		if got := rogerr.Metadata(rebuilt); !reflect.DeepEqual(exp, got) {
+			t.Errorf("expected metadata %v but got %v", exp, got)
+		}
+		if got := len(rogerr.MetadataLayers(rebuilt)); got != 2 {
+			t.Errorf("expected 2 metadata layers but got %d", got)
+		}
+	})
+
+	t.Run("stacktrace", func(t *testing.T) {
+		exp, got := handler.Stacktrace(original), handler.Stacktrace(rebuilt)
+		if len(got) == 0 || !reflect.DeepEqual(exp, got) {
+			t.Errorf("expected stacktrace %v but got %v", exp, got)
+		}
+	})
+
+	t.Run("breadcrumbs", func(t *testing.T) {
+		got := rogerr.Breadcrumbs(rebuilt)
+		if len(got) != 1 || got[0].Message != "started" || got[0].Data["attempt"] != float64(1) {
+			t.Fatalf("expected the original breadcrumb but got %+v", got)
+		}
+		if exp := rogerr.Breadcrumbs(original)[0].Time; !got[0].Time.Equal(exp) {
+			t.Errorf("expected breadcrumb time %v but got %v", exp, got[0].Time)
+		}
+	})
+
+	t.Run("cause chain", func(t *testing.T) {
+		cause := errors.Unwrap(rebuilt)
+		if cause == nil || cause.Error() != "fmt: inner: low level" {
+			t.Fatalf("unexpected cause %v", cause)
+		}
+		if again, err := json.Marshal(rebuilt); err != nil || string(again) != string(data) {
+			t.Errorf("expected re-marshalling to give identical JSON\nexp: %s\ngot: %s", data, again)
+		}
+	})
+}
+
+func TestJSONMarshalling(t *testing.T) {
+	t.Run("values that cannot be marshalled degrade to strings", func(t *testing.T) {
+		ctx := rogerr.WithMetadata(context.Background(), map[string]interface{}{"nan": math.NaN(), "ch": make(chan int)})
+		data, err := json.Marshal(rogerr.NewErrorHandler(rogerr.WithStacktrace(false)).Wrap(ctx, nil, "oops"))
+		if err != nil {
+			t.Fatalf("unable to marshal error: %v", err)
+		}
+		rebuilt, err := rogerr.UnmarshalError(data)
+		if err != nil {
+			t.Fatalf("unable to unmarshal error: %v", err)
+		}
+		if got := rogerr.Metadata(rebuilt)["nan"]; got != "NaN" {
+			t.Errorf("expected NaN to be marshalled as a string but got %v", got)
+		}
+		if got, ok := rogerr.Metadata(rebuilt)["ch"].(string); !ok || got == "" {
+			t.Errorf("expected channel to be marshalled as a string but got %v", got)
+		}
+	})
+
+	t.Run("joined errors", func(t *testing.T) {
+		joined := errors.Join(errors.New("first"), rogerr.Wrap(rogerr.WithMetadatum(context.Background(), "k", "v"), nil, "second"))
+		data, err := json.Marshal(rogerr.Wrap(nil, joined, "outer"))
+		if err != nil {
+			t.Fatalf("unable to marshal error: %v", err)
+		}
+		rebuilt, err := rogerr.UnmarshalError(data)
+		if err != nil {
+			t.Fatalf("unable to unmarshal error: %v", err)
+		}
+		if exp, got := "outer: first\nsecond", rebuilt.Error(); exp != got {
+			t.Errorf("expected message %q but got %q", exp, got)
+		}
+		if got := rogerr.Metadata(rebuilt)["k"]; got != "v" {
+			t.Errorf("expected metadata from joined branch but got %v", got)
+		}
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		if _, err := rogerr.UnmarshalError([]byte("{")); err == nil {
+			t.Error("expected an error for invalid JSON")
+		}
+	})
+
+	t.Run("null", func(t *testing.T) {
+		if got, err := rogerr.UnmarshalError([]byte("null")); got != nil || err != nil {
+			t.Errorf("expected nil, nil but got %v, %v", got, err)
+		}
+	})
+}
+
+func TestErrorType(t *testing.T) {
+	original := rogerr.NewErrorHandler().Wrap(context.Background(), &customErr{}, "wrapped")
+	data, _ := json.Marshal(original)
+	rebuilt, _ := rogerr.UnmarshalError(data)
+
+	for name, tc := range map[string]struct {
+		err error
+		exp string
+	}{
+		"standard error":  {err: errors.New("oops"), exp: "*errors.errorString"},
+		"rogerr layer":    {err: original, exp: "rogerr.Error"},
+		"rebuilt rogerr":  {err: rebuilt, exp: "rogerr.Error"},
+		"rebuilt foreign": {err: errors.Unwrap(rebuilt), exp: "*rogerr_test.customErr"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got := rogerr.ErrorType(tc.err); got != tc.exp {
+				t.Errorf("expected %s but got %s", tc.exp, got)
+			}
+		})
+	}
+
+	t.Run("matches the JSON type", func(t *testing.T) {
+		var j struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(data, &j); err != nil || j.Type != rogerr.ErrorType(original) {
+			t.Errorf("expected JSON type %s but got %s", rogerr.ErrorType(original), j.Type)
+		}
+	})
+}
+
+type customErr struct{}
+
+func (*customErr) Error() string { return "custom" }
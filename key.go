@@ -0,0 +1,43 @@
+package rogerr
+
+import "context"
+
+// Key is a typed metadata key. Declare keys once, typically as package-level
+// variables, so that the key name and value type are defined in one place:
+//
+//	var userID = rogerr.NewKey[int]("userID")
+//
+// Values set with a Key are stored in the same metadata as WithMetadatum,
+// and so show up under the key's name in Metadata.
+type Key[T any] struct {
+	name string
+}
+
+// NewKey creates a new typed metadata key with the given name.
+func NewKey[T any](name string) Key[T] {
+	return Key[T]{name: name}
+}
+
+// Name returns the name the key's values are stored under in Metadata.
+func (k Key[T]) Name() string {
+	return k.name
+}
+
+// Set attaches the given value to the rogerr metadata associated with this context.
+// Returns a new context with the value attached, or nil if the given ctx was nil.
+func (k Key[T]) Set(ctx context.Context, value T) context.Context {
+	return WithMetadatum(ctx, k.name, value)
+}
+
+// Get extracts the value stored under this key from the given error's metadata.
+// Returns false if there was no value, or if the value was not of type T.
+// The value is redacted as with Metadata, and only this key's value is resolved.
+func (k Key[T]) Get(err error) (T, bool) {
+	var zero T
+	v, ok := mergeMetadata(metadataLayers(err), MergeOutermostWins)[k.name]
+	if !ok {
+		return zero, false
+	}
+	value, ok := exportHandler(err).redaction.redact(map[string]interface{}{k.name: v})[k.name].(T)
+	return value, ok
+}
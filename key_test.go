@@ -0,0 +1,68 @@
+package rogerr_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kinbiko/rogerr"
+)
+
+func TestKey(t *testing.T) {
+	userID := rogerr.NewKey[int]("userID")
+
+	t.Run("round trip", func(t *testing.T) {
+		err := rogerr.Wrap(userID.Set(context.Background(), 123), nil, "oops")
+		got, ok := userID.Get(err)
+		if !ok {
+			t.Fatal("expected value to be found")
+		}
+		if got != 123 {
+			t.Errorf("expected 123 but got %d", got)
+		}
+	})
+
+	t.Run("visible as a plain string key in Metadata", func(t *testing.T) {
+		err := rogerr.Wrap(userID.Set(context.Background(), 123), nil, "oops")
+		if got := rogerr.Metadata(err)[userID.Name()]; got != 123 {
+			t.Errorf("expected 123 under key '%s' but got %v", userID.Name(), got)
+		}
+	})
+
+	t.Run("missing value", func(t *testing.T) {
+		if got, ok := userID.Get(errors.New("plain")); ok || got != 0 {
+			t.Errorf("expected zero value and false but got %d, %v", got, ok)
+		}
+	})
+
+	t.Run("value of a different type", func(t *testing.T) {
+		err := rogerr.Wrap(rogerr.WithMetadatum(context.Background(), "userID", "123"), nil, "oops")
+		if got, ok := userID.Get(err); ok || got != 0 {
+			t.Errorf("expected zero value and false but got %d, %v", got, ok)
+		}
+	})
+
+	t.Run("only the key's value is resolved", func(t *testing.T) {
+		ctx := rogerr.WithMetadatum(context.Background(), "db.stats", rogerr.Lazy(func() any {
+			t.Error("expected the value of another key not to be computed")
+			return nil
+		}))
+		ctx = rogerr.WithMetadatum(ctx, userID.Name(), rogerr.Lazy(func() any { return 123 }))
+		if got, ok := userID.Get(rogerr.Wrap(ctx, nil, "oops")); !ok || got != 123 {
+			t.Errorf("expected 123 but got %d, %v", got, ok)
+		}
+	})
+
+	t.Run("redacted like Metadata", func(t *testing.T) {
+		err := rogerr.NewErrorHandler(rogerr.WithRedactedKeys("userID")).Wrap(userID.Set(context.Background(), 123), nil, "oops")
+		if got, ok := userID.Get(err); ok || got != 0 {
+			t.Errorf("expected the value to be redacted but got %d, %v", got, ok)
+		}
+	})
+
+	t.Run("nil ctx", func(t *testing.T) {
+		if ctx := userID.Set(nil, 123); ctx != nil { //nolint:staticcheck // Testing that we don't do a dumb when users do a dumb
+			t.Errorf("expected nil ctx but got %v", ctx)
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package rogerr
+
+import (
+	"fmt"
+	"sync"
+)
+
+// maxValuerResolutions is the maximum number of times a Valuer whose value
+// is another Valuer is resolved, to guard against cycles.
+const maxValuerResolutions = 100
+
+// Valuer is implemented by metadata values that are resolved on demand,
+// when metadata is extracted from a context or an error, similarly to
+// slog.LogValuer. If Value panics, the metadatum's value is a string
+// describing the panic instead.
+// Value is called every time the metadatum is read, e.g. once per export;
+// use Lazy for values that should only be computed once.
+type Valuer interface {
+	Value() any
+}
+
+// Lazy returns a Valuer for values that are costly to compute, e.g. a
+// serialised request body, and are only worth computing if an error is
+// actually reported:
+//
+//	ctx = rogerr.WithMetadatum(ctx, "db.pool", rogerr.Lazy(func() any { return db.Stats() }))
+//
+// fn is called at most once, the first time the value is needed, and the
+// result is reused from then on. Values of redacted keys are never computed
+// unless they are to be hashed.
+func Lazy(fn func() any) Valuer {
+	return &lazyValue{fn: fn}
+}
+
+type lazyValue struct {
+	fn    func() any
+	once  sync.Once
+	value any
+}
+
+func (l *lazyValue) Value() any {
+	l.once.Do(func() {
+		l.value = safeValue(l.fn)
+	})
+	return l.value
+}
+
+// String returns the %v representation of the value.
+func (l *lazyValue) String() string {
+	return fmt.Sprintf("%v", l.Value())
+}
+
+// resolve returns the value of the given Valuer, or the given value as is if
+// it isn't a Valuer.
+func resolve(v any) any {
+	for i := 0; i < maxValuerResolutions; i++ {
+		valuer, ok := v.(Valuer)
+		if !ok {
+			return v
+		}
+		v = safeValue(valuer.Value)
+	}
+	return fmt.Sprintf("!ERROR: more than %d nested Valuers", maxValuerResolutions)
+}
+
+// safeValue calls fn, returning a description of the panic if it panics.
+func safeValue(fn func() any) (v any) {
+	defer func() {
+		if r := recover(); r != nil {
+			v = fmt.Sprintf("!PANIC: %v", r)
+		}
+	}()
+	return fn()
+}
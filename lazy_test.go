@@ -0,0 +1,101 @@
+package rogerr
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type valuerFunc func() any
+
+func (f valuerFunc) Value() any { return f() }
+
+func TestLazy(t *testing.T) {
+	h := NewErrorHandler(WithStacktrace(false))
+
+	t.Run("resolved once on demand", func(t *testing.T) {
+		calls := 0
+		ctx := WithMetadatum(context.Background(), "stats", Lazy(func() any {
+			calls++
+			return map[string]int{"open": 3}
+		}))
+		err := h.Wrap(ctx, nil, "oops")
+		if calls != 0 {
+			t.Fatal("expected the value not to be computed when wrapping")
+		}
+		for i := 0; i < 3; i++ {
+			if got := Metadata(err)["stats"]; !reflect.DeepEqual(got, map[string]int{"open": 3}) {
+				t.Errorf("expected the computed value but got %v", got)
+			}
+		}
+		if got := ContextMetadata(ctx)["stats"]; !reflect.DeepEqual(got, map[string]int{"open": 3}) {
+			t.Errorf("expected the computed value but got %v", got)
+		}
+		if calls != 1 {
+			t.Errorf("expected the value to be computed once but it was computed %d times", calls)
+		}
+	})
+
+	t.Run("exporters see resolved values", func(t *testing.T) {
+		err := h.Wrap(WithMetadatum(context.Background(), "n", Lazy(func() any { return 42 })), nil, "oops")
+		if got := fmt.Sprintf("%+v", err); !strings.Contains(got, "n: 42") {
+			t.Errorf("expected the resolved value in %q", got)
+		}
+		if got, _ := h.MetadataSet(err).Get("n"); got != 42 {
+			t.Errorf("expected 42 but got %v", got)
+		}
+	})
+
+	t.Run("panics are recovered", func(t *testing.T) {
+		ctx := WithMetadatum(context.Background(), "boom", Lazy(func() any { panic("kaboom") }))
+		if got := Metadata(h.Wrap(ctx, nil, "oops"))["boom"]; got != "!PANIC: kaboom" {
+			t.Errorf("expected a panic description but got %v", got)
+		}
+	})
+
+	t.Run("Valuer implementations", func(t *testing.T) {
+		calls := 0
+		v := valuerFunc(func() any {
+			calls++
+			return Lazy(func() any { return "nested" })
+		})
+		if got := ContextMetadata(WithMetadatum(context.Background(), "v", v))["v"]; got != "nested" {
+			t.Errorf("expected nested Valuers to be resolved but got %v", got)
+		}
+		var cyclic valuerFunc
+		cyclic = func() any { return cyclic }
+		if got := ContextMetadata(WithMetadatum(context.Background(), "v", cyclic))["v"]; !strings.HasPrefix(fmt.Sprint(got), "!ERROR") {
+			t.Errorf("expected cyclic Valuers to give an error but got %v", got)
+		}
+	})
+
+	t.Run("redacted values are not computed", func(t *testing.T) {
+		h := NewErrorHandler(WithRedactedKeys("body"))
+		ctx := WithMetadatum(context.Background(), "body", Lazy(func() any {
+			t.Error("expected the redacted value not to be computed")
+			return nil
+		}))
+		if got := h.Metadata(h.Wrap(ctx, nil, "oops")); len(got) != 0 {
+			t.Errorf("expected no metadata but got %v", got)
+		}
+	})
+
+	t.Run("sensitive values are redacted", func(t *testing.T) {
+		ctx := WithMetadatum(context.Background(), "token", Lazy(func() any { return sensitiveValue("s3cr3t") }))
+		if got := h.Metadata(h.Wrap(ctx, nil, "oops")); len(got) != 0 {
+			t.Errorf("expected no metadata but got %v", got)
+		}
+	})
+
+	t.Run("String", func(t *testing.T) {
+		if got := fmt.Sprint(Lazy(func() any { return 1 })); got != "1" {
+			t.Errorf("expected 1 but got %s", got)
+		}
+	})
+}
+
+type sensitiveValue string
+
+func (sensitiveValue) Sensitive() {}
@@ -0,0 +1,90 @@
+package rogerr
+
+import (
+	"iter"
+	"slices"
+	"sort"
+)
+
+// MetadataSet is an immutable snapshot of metadata, whose keys are always
+// iterated in sorted order, so that output built from it is deterministic.
+// The zero value is an empty set.
+type MetadataSet struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// NewMetadataSet creates a MetadataSet with the entries of the given map,
+// which is copied, so later changes to it will not affect the set.
+func NewMetadataSet(md map[string]interface{}) MetadataSet {
+	s := MetadataSet{keys: make([]string, 0, len(md)), values: make(map[string]interface{}, len(md))}
+	for k, v := range md {
+		s.keys = append(s.keys, k)
+		s.values[k] = v
+	}
+	sort.Strings(s.keys)
+	return s
+}
+
+// MetadataSetOf is like Metadata, but returns a MetadataSet rather than a map.
+func MetadataSetOf(err error) MetadataSet {
+	return NewMetadataSet(Metadata(err))
+}
+
+// MetadataSet is like Metadata, but returns a MetadataSet rather than a map.
+func (h *ErrorHandler) MetadataSet(err error) MetadataSet {
+	return NewMetadataSet(h.Metadata(err))
+}
+
+// All returns an iterator over the keys and values of the set, sorted by key.
+func (s MetadataSet) All() iter.Seq2[string, any] {
+	return func(yield func(string, any) bool) {
+		for _, k := range s.keys {
+			if !yield(k, s.values[k]) {
+				return
+			}
+		}
+	}
+}
+
+// Get returns the value of the given key, and whether the set has the key.
+func (s MetadataSet) Get(key string) (any, bool) {
+	v, ok := s.values[key]
+	return v, ok
+}
+
+// Len returns the number of entries in the set.
+func (s MetadataSet) Len() int {
+	return len(s.keys)
+}
+
+// Keys returns the keys of the set in sorted order.
+func (s MetadataSet) Keys() []string {
+	return slices.Clone(s.keys)
+}
+
+// With returns a copy of the set with the given key set to the given value.
+func (s MetadataSet) With(key string, value any) MetadataSet {
+	md := s.ToMap()
+	md[key] = value
+	return NewMetadataSet(md)
+}
+
+// Without returns a copy of the set without the given keys.
+func (s MetadataSet) Without(keys ...string) MetadataSet {
+	md := s.ToMap()
+	for _, k := range keys {
+		delete(md, k)
+	}
+	return NewMetadataSet(md)
+}
+
+// ToMap returns the entries of the set as a new map, which can be modified
+// freely by the caller.
+func (s MetadataSet) ToMap() map[string]interface{} {
+	md := make(map[string]interface{}, len(s.keys))
+	for k, v := range s.values {
+		md[k] = v
+	}
+	return md
+}
@@ -0,0 +1,95 @@
+package rogerr
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestMetadataSet(t *testing.T) {
+	md := map[string]interface{}{"c": 3, "a": 1, "b": 2}
+	s := NewMetadataSet(md)
+	md["d"] = 4
+
+	t.Run("sorted iteration", func(t *testing.T) {
+		keys, values := []string{}, []interface{}{}
+		for k, v := range s.All() {
+			keys, values = append(keys, k), append(values, v)
+		}
+		if !reflect.DeepEqual(keys, []string{"a", "b", "c"}) || !reflect.DeepEqual(values, []interface{}{1, 2, 3}) {
+			t.Errorf("expected sorted entries but got %v %v", keys, values)
+		}
+		for k := range s.All() {
+			if k != "a" {
+				t.Errorf("expected iteration to stop after the first key but got %s", k)
+			}
+			break
+		}
+	})
+
+	t.Run("accessors", func(t *testing.T) {
+		if v, ok := s.Get("b"); !ok || v != 2 {
+			t.Errorf("expected b to be 2 but got %v %v", v, ok)
+		}
+		if _, ok := s.Get("d"); ok {
+			t.Error("expected the set not to be affected by changes to the given map")
+		}
+		if s.Len() != 3 {
+			t.Errorf("expected 3 entries but got %d", s.Len())
+		}
+		keys := s.Keys()
+		keys[0] = "z"
+		if !reflect.DeepEqual(s.Keys(), []string{"a", "b", "c"}) {
+			t.Errorf("expected Keys to return a copy but got %v", s.Keys())
+		}
+	})
+
+	t.Run("With and Without return copies", func(t *testing.T) {
+		with := s.With("a", 10).With("0", 0)
+		if !reflect.DeepEqual(with.Keys(), []string{"0", "a", "b", "c"}) {
+			t.Errorf("unexpected keys %v", with.Keys())
+		}
+		if v, _ := with.Get("a"); v != 10 {
+			t.Errorf("expected a to be 10 but got %v", v)
+		}
+		without := s.Without("a", "missing")
+		if !reflect.DeepEqual(without.Keys(), []string{"b", "c"}) {
+			t.Errorf("unexpected keys %v", without.Keys())
+		}
+		if v, _ := s.Get("a"); v != 1 || s.Len() != 3 {
+			t.Error("expected the original set to be unchanged")
+		}
+	})
+
+	t.Run("ToMap returns a copy", func(t *testing.T) {
+		m := s.ToMap()
+		m["a"] = 100
+		if v, _ := s.Get("a"); v != 1 {
+			t.Error("expected the set not to be affected by changes to the map")
+		}
+		if !reflect.DeepEqual(NewMetadataSet(m).Without("a").ToMap(), map[string]interface{}{"b": 2, "c": 3}) {
+			t.Errorf("unexpected map %v", m)
+		}
+	})
+
+	t.Run("zero value", func(t *testing.T) {
+		var zero MetadataSet
+		if zero.Len() != 0 || len(zero.Keys()) != 0 || len(zero.ToMap()) != 0 {
+			t.Error("expected an empty set")
+		}
+		if v, _ := zero.With("a", 1).Get("a"); v != 1 {
+			t.Error("expected With to work on the zero value")
+		}
+	})
+
+	t.Run("from errors", func(t *testing.T) {
+		h := NewErrorHandler(WithStacktrace(false), WithRedactedKeys("secret"))
+		err := h.Wrap(WithMetadata(context.Background(), map[string]interface{}{"b": 2, "a": 1, "secret": "s"}), nil, "oops")
+		if got := MetadataSetOf(err).Keys(); !reflect.DeepEqual(got, []string{"a", "b"}) {
+			t.Errorf("unexpected keys %v", got)
+		}
+		if got := h.MetadataSet(err).ToMap(); !reflect.DeepEqual(got, h.Metadata(err)) {
+			t.Errorf("expected the handler's metadata but got %v", got)
+		}
+	})
+}
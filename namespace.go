@@ -0,0 +1,108 @@
+package rogerr
+
+import (
+	"context"
+	"strings"
+)
+
+// Namespace qualifies metadata keys, so that libraries and applications that
+// attach metadata to the same context don't clobber each other's keys:
+//
+//	ctx = rogerr.Namespace("mylib").WithMetadatum(ctx, "id", id) // stored as "mylib.id"
+//
+// Namespaced metadata is flattened into qualified keys by Metadata and
+// friends, which is also what redaction rules match against. NestedMetadata
+// gives each namespace a map of its own instead.
+type Namespace string
+
+// WithMetadatum attaches the given key, qualified with this namespace, and
+// value to the rogerr metadata associated with this context.
+// Returns a new context with the metadatum attached, or nil if the given ctx was nil.
+func (ns Namespace) WithMetadatum(ctx context.Context, key string, value interface{}) context.Context {
+	return ns.WithMetadata(ctx, map[string]interface{}{key: value})
+}
+
+// WithMetadata attaches the given keys, qualified with this namespace, and
+// values to the rogerr metadata associated with this context.
+// Returns a new context with the metadata attached, or nil if the given ctx was nil.
+// The given map is copied, so later changes to it will not affect the returned context.
+func (ns Namespace) WithMetadata(ctx context.Context, data map[string]interface{}) context.Context {
+	if ctx == nil {
+		return nil
+	}
+	cd := getCtxData(ctx)
+	md := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		md[ns.qualify(k)] = v
+	}
+	cd.metadata = &metadataNode{parent: cd.metadata, data: md, namespace: ns}
+	return context.WithValue(ctx, ctxDataKey, &cd)
+}
+
+// qualify returns the given key qualified with this namespace, e.g. "mylib.id" for "id".
+func (ns Namespace) qualify(key string) string {
+	if ns == "" {
+		return key
+	}
+	return string(ns) + "." + key
+}
+
+// NestedMetadata is like Metadata, except that the metadata of each
+// namespace is in a map[string]interface{} of its own under the name of the
+// namespace, keyed by the unqualified keys, e.g. {"mylib": {"id": 1}} rather
+// than {"mylib.id": 1}. A namespace takes precedence over a key without a
+// namespace of the same name. The metadata is redacted as with Metadata.
+func NestedMetadata(err error) map[string]interface{} {
+	return exportHandler(err).NestedMetadata(err)
+}
+
+// NestedMetadata is like Metadata, except that the metadata of each
+// namespace is nested as with the package level NestedMetadata.
+func (h *ErrorHandler) NestedMetadata(err error) map[string]interface{} {
+	namespaces := map[string]Namespace{}
+	for _, e := range rErrors(err) {
+		_, layerNamespaces := getNamespacedMetadata(e.ctx)
+		for k, ns := range layerNamespaces {
+			if _, ok := namespaces[k]; !ok {
+				namespaces[k] = ns
+			}
+		}
+	}
+	return nest(h.Metadata(err), namespaces)
+}
+
+// NestedContextMetadata is like ContextMetadata, except that the metadata of
+// each namespace is nested as with NestedMetadata.
+func (h *ErrorHandler) NestedContextMetadata(ctx context.Context) map[string]interface{} {
+	_, namespaces := getNamespacedMetadata(ctx)
+	return nest(h.ContextMetadata(ctx), namespaces)
+}
+
+// nest moves the entries of the given metadata whose keys have a namespace
+// into a map of their own under the name of the namespace.
+func nest(md map[string]interface{}, namespaces map[string]Namespace) map[string]interface{} {
+	if md == nil {
+		return nil
+	}
+	nested := make(map[string]interface{}, len(md))
+	groups := map[string]map[string]interface{}{}
+	for k, v := range md {
+		ns, ok := namespaces[k]
+		if !ok {
+			continue
+		}
+		group := groups[string(ns)]
+		if group == nil {
+			group = map[string]interface{}{}
+			groups[string(ns)] = group
+			nested[string(ns)] = group
+		}
+		group[strings.TrimPrefix(k, string(ns)+".")] = v
+	}
+	for k, v := range md {
+		if _, ok := namespaces[k]; !ok && groups[k] == nil {
+			nested[k] = v
+		}
+	}
+	return nested
+}
@@ -0,0 +1,86 @@
+package rogerr
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestNamespace(t *testing.T) {
+	h := NewErrorHandler(WithStacktrace(false))
+	mylib := Namespace("mylib")
+
+	t.Run("qualifies keys", func(t *testing.T) {
+		ctx := WithMetadatum(context.Background(), "id", "app")
+		ctx = mylib.WithMetadatum(ctx, "id", "lib")
+		ctx = mylib.WithMetadata(ctx, map[string]interface{}{"retries": 3})
+
+		exp := map[string]interface{}{"id": "app", "mylib.id": "lib", "mylib.retries": 3}
+		if got := ContextMetadata(ctx); !reflect.DeepEqual(got, exp) {
+			t.Errorf("expected %v but got %v", exp, got)
+		}
+		if got := Metadata(h.Wrap(ctx, nil, "oops")); !reflect.DeepEqual(got, exp) {
+			t.Errorf("expected %v but got %v", exp, got)
+		}
+	})
+
+	t.Run("nested metadata", func(t *testing.T) {
+		ctx := WithMetadata(context.Background(), map[string]interface{}{"id": "app", "mylib": "shadowed", "http.route": "/"})
+		inner := h.Wrap(mylib.WithMetadatum(ctx, "id", "lib"), nil, "inner")
+		err := fmt.Errorf("fmt: %w", h.Wrap(Namespace("other").WithMetadatum(ctx, "id", 1), inner, "outer"))
+
+		exp := map[string]interface{}{
+			"id":         "app",
+			"http.route": "/",
+			"mylib":      map[string]interface{}{"id": "lib"},
+			"other":      map[string]interface{}{"id": 1},
+		}
+		if got := NestedMetadata(err); !reflect.DeepEqual(got, exp) {
+			t.Errorf("expected %v but got %v", exp, got)
+		}
+	})
+
+	t.Run("unqualified keys replace namespaced keys", func(t *testing.T) {
+		ctx := mylib.WithMetadatum(context.Background(), "id", "lib")
+		ctx = WithMetadatum(ctx, "mylib.id", "flat")
+		exp := map[string]interface{}{"mylib.id": "flat"}
+		if got := h.NestedContextMetadata(ctx); !reflect.DeepEqual(got, exp) {
+			t.Errorf("expected %v but got %v", exp, got)
+		}
+	})
+
+	t.Run("redaction matches qualified keys", func(t *testing.T) {
+		h := NewErrorHandler(WithRedactedKeys("mylib.*"))
+		ctx := mylib.WithMetadatum(WithMetadatum(context.Background(), "id", "app"), "token", "secret")
+		exp := map[string]interface{}{"id": "app"}
+		if got := h.NestedContextMetadata(ctx); !reflect.DeepEqual(got, exp) {
+			t.Errorf("expected %v but got %v", exp, got)
+		}
+	})
+
+	t.Run("redacted like Metadata", func(t *testing.T) {
+		ctx := mylib.WithMetadatum(WithMetadatum(context.Background(), "id", "app"), "email", "a@b.c")
+		err := NewErrorHandler(WithRedactedKeys("mylib.email")).Wrap(context.Background(), h.Wrap(ctx, nil, "inner"), "outer")
+		exp := map[string]interface{}{"id": "app"}
+		if got := NestedMetadata(err); !reflect.DeepEqual(got, exp) {
+			t.Errorf("expected %v but got %v", exp, got)
+		}
+	})
+
+	t.Run("empty namespace", func(t *testing.T) {
+		ctx := Namespace("").WithMetadatum(context.Background(), "id", 1)
+		if got := NestedMetadata(h.Wrap(ctx, nil, "oops")); !reflect.DeepEqual(got, map[string]interface{}{"id": 1}) {
+			t.Errorf("expected unqualified keys but got %v", got)
+		}
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		if mylib.WithMetadatum(nil, "id", 1) != nil { //nolint:staticcheck // testing nil ctx
+			t.Error("expected nil context")
+		}
+		if got := NestedMetadata(nil); got != nil {
+			t.Errorf("expected nil metadata but got %v", got)
+		}
+	})
+}
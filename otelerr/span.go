@@ -0,0 +1,121 @@
+/*
+Package otelerr records rogerr errors as OpenTelemetry exception span events,
+without depending on the OpenTelemetry SDK.
+
+Any span that implements the Span interface can be used. Spans from the
+OpenTelemetry SDK need a small adapter, e.g.:
+
+	type otelSpan struct{ trace.Span }
+
+	func (s otelSpan) AddEvent(name string, attrs []otelerr.Attribute) {
+		kvs := make([]attribute.KeyValue, len(attrs))
+		for i, a := range attrs {
+			kvs[i] = attribute.String(a.Key, fmt.Sprint(a.Value))
+		}
+		s.Span.AddEvent(name, trace.WithAttributes(kvs...))
+	}
+
+	func (s otelSpan) SetStatus(code otelerr.StatusCode, description string) {
+		s.Span.SetStatus(codes.Code(code), description)
+	}
+*/
+package otelerr
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kinbiko/rogerr"
+)
+
+// Attribute is a key-value pair attached to a span event.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// StatusCode is the status of a span.
+// The values match the status codes of the OpenTelemetry SDK.
+type StatusCode int
+
+const (
+	// StatusUnset is the default status of a span.
+	StatusUnset StatusCode = iota
+	// StatusError indicates that the operation of the span failed.
+	StatusError
+	// StatusOK indicates that the operation of the span succeeded.
+	StatusOK
+)
+
+// Span is the subset of an OpenTelemetry span used to record errors.
+type Span interface {
+	AddEvent(name string, attrs []Attribute)
+	SetStatus(code StatusCode, description string)
+}
+
+// Recorder records errors on spans.
+type Recorder struct {
+	errors *rogerr.ErrorHandler
+}
+
+// Option is a function that configures a Recorder.
+type Option func(*Recorder)
+
+// WithErrorHandler configures the rogerr.ErrorHandler used to extract
+// metadata and stacktraces from recorded errors.
+func WithErrorHandler(errorHandler *rogerr.ErrorHandler) Option {
+	return func(r *Recorder) {
+		r.errors = errorHandler
+	}
+}
+
+// NewRecorder creates a new Recorder with the given options.
+func NewRecorder(opts ...Option) *Recorder {
+	r := &Recorder{errors: rogerr.NewErrorHandler()}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// RecordError adds an "exception" event for the given error to the given
+// span, and sets the span's status to StatusError.
+// Does nothing if err is nil.
+func (r *Recorder) RecordError(span Span, err error) {
+	if err == nil {
+		return
+	}
+	span.AddEvent("exception", r.Attributes(err))
+	span.SetStatus(StatusError, err.Error())
+}
+
+// Attributes returns the OpenTelemetry exception event attributes for the
+// given error, followed by the error's metadata ordered by key.
+// Returns nil if err is nil.
+func (r *Recorder) Attributes(err error) []Attribute {
+	if err == nil {
+		return nil
+	}
+	attrs := []Attribute{
+		{Key: "exception.type", Value: rogerr.ErrorType(rogerr.RootCause(err))},
+		{Key: "exception.message", Value: err.Error()},
+	}
+	if frames := r.errors.Stacktrace(err); len(frames) > 0 {
+		attrs = append(attrs, Attribute{Key: "exception.stacktrace", Value: formatStacktrace(frames)})
+	}
+
+	for k, v := range r.errors.MetadataSet(err).All() {
+		attrs = append(attrs, Attribute{Key: k, Value: v})
+	}
+	return attrs
+}
+
+// formatStacktrace formats the given frames the way the Go runtime formats
+// goroutine stacks, e.g. in panics and runtime/debug.Stack.
+func formatStacktrace(frames []rogerr.Frame) string {
+	var sb strings.Builder
+	for _, f := range frames {
+		fmt.Fprintf(&sb, "%s(...)\n\t%s:%d\n", f.Function, f.File, f.Line)
+	}
+	return sb.String()
+}
@@ -0,0 +1,92 @@
+package otelerr_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kinbiko/rogerr"
+	"github.com/kinbiko/rogerr/otelerr"
+)
+
+type fakeSpan struct {
+	events      map[string][]otelerr.Attribute
+	status      otelerr.StatusCode
+	description string
+}
+
+func (s *fakeSpan) AddEvent(name string, attrs []otelerr.Attribute) {
+	if s.events == nil {
+		s.events = map[string][]otelerr.Attribute{}
+	}
+	s.events[name] = attrs
+}
+
+func (s *fakeSpan) SetStatus(code otelerr.StatusCode, description string) {
+	s.status, s.description = code, description
+}
+
+func TestRecordError(t *testing.T) {
+	ctx := rogerr.WithMetadata(context.Background(), map[string]interface{}{"userID": 123, "requestID": "abc"})
+	err := fmt.Errorf("fmt: %w", rogerr.NewErrorHandler().Wrap(ctx, errors.New("low level"), "wrapped"))
+
+	t.Run("exception event and status", func(t *testing.T) {
+		span := &fakeSpan{}
+		otelerr.NewRecorder().RecordError(span, err)
+
+		if span.status != otelerr.StatusError || span.description != err.Error() {
+			t.Errorf("unexpected status %v with description %q", span.status, span.description)
+		}
+		attrs, ok := span.events["exception"]
+		if !ok {
+			t.Fatalf("expected exception event but got %v", span.events)
+		}
+
+		exp := []string{"exception.type", "exception.message", "exception.stacktrace", "requestID", "userID"}
+		if len(attrs) != len(exp) {
+			t.Fatalf("expected attributes %v but got %v", exp, attrs)
+		}
+		for i, key := range exp {
+			if attrs[i].Key != key {
+				t.Errorf("expected attribute %d to be %s but was %s", i, key, attrs[i].Key)
+			}
+		}
+		if attrs[0].Value != "*errors.errorString" || attrs[1].Value != "fmt: wrapped: low level" {
+			t.Errorf("unexpected exception attributes %v", attrs[:2])
+		}
+		stacktrace, _ := attrs[2].Value.(string)
+		if !strings.HasPrefix(stacktrace, "github.com/kinbiko/rogerr/otelerr_test.TestRecordError(...)\n\t") {
+			t.Errorf("unexpected stacktrace format:\n%s", stacktrace)
+		}
+	})
+
+	t.Run("stacktrace omitted when disabled", func(t *testing.T) {
+		handler := rogerr.NewErrorHandler(rogerr.WithStacktrace(false))
+		attrs := otelerr.NewRecorder(otelerr.WithErrorHandler(handler)).Attributes(handler.Wrap(nil, nil, "oops"))
+		for _, a := range attrs {
+			if a.Key == "exception.stacktrace" {
+				t.Errorf("expected no stacktrace attribute but got %v", a.Value)
+			}
+		}
+	})
+
+	t.Run("nil error", func(t *testing.T) {
+		span := &fakeSpan{}
+		otelerr.NewRecorder().RecordError(span, nil)
+		if span.events != nil || span.status != otelerr.StatusUnset {
+			t.Errorf("expected span to be untouched but got %+v", span)
+		}
+		if attrs := otelerr.NewRecorder().Attributes(nil); attrs != nil {
+			t.Errorf("expected no attributes but got %v", attrs)
+		}
+	})
+
+	t.Run("rogerr root cause", func(t *testing.T) {
+		attrs := otelerr.NewRecorder().Attributes(rogerr.NewErrorHandler().Wrap(nil, nil, "oops"))
+		if attrs[0].Value != "rogerr.Error" {
+			t.Errorf("expected exception type rogerr.Error but got %v", attrs[0].Value)
+		}
+	})
+}
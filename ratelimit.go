@@ -0,0 +1,256 @@
+package rogerr
+
+import (
+	"context"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// maxSuppressedValues is the maximum number of distinct values of each
+	// metadata key that are kept for a suppression summary.
+	maxSuppressedValues = 10
+
+	defaultRatePerSecond   = 1
+	defaultBurst           = 10
+	defaultSummaryInterval = time.Minute
+)
+
+// ReportFunc reports an error, e.g. by logging it or by sending it to an
+// error tracking service.
+type ReportFunc func(ctx context.Context, err error)
+
+// RateLimiter sits in front of a ReportFunc and keeps a flood of identical
+// errors from reaching it. Errors are grouped by their Fingerprint, and each
+// group is sampled and rate limited with a token bucket of its own.
+//
+// Errors that are dropped are counted, and a summary error with the message
+// "N similar errors suppressed" is periodically reported in their place. The
+// summary wraps the most recently suppressed error, and carries the distinct
+// values of the suppressed errors' metadata other than Valuers, which are
+// never resolved for suppressed errors, along with the metadata keys
+// "suppressed.count", "suppressed.fingerprint", and "suppressed.since".
+//
+// Summaries are reported by calls to Report once the summary interval has
+// passed, and by Flush, which should be called before the program exits.
+// A RateLimiter starts no goroutines, and is safe for concurrent use.
+type RateLimiter struct {
+	next            ReportFunc
+	errors          *ErrorHandler
+	rate            float64
+	burst           float64
+	sampleRate      float64
+	summaryInterval time.Duration
+	now             func() time.Time
+	random          func() float64
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// bucket holds the token bucket and suppression state of a single fingerprint.
+type bucket struct {
+	tokens     float64
+	updated    time.Time
+	suppressed int
+	since      time.Time
+	last       error
+	metadata   map[string][]interface{}
+}
+
+// RateLimitOption is a function that configures a RateLimiter.
+type RateLimitOption func(*RateLimiter)
+
+// WithRateLimit configures the number of errors per second that are reported
+// for each fingerprint, and the number of errors that may be reported in a
+// burst. Defaults to 1 per second with bursts of 10.
+func WithRateLimit(perSecond float64, burst int) RateLimitOption {
+	return func(r *RateLimiter) {
+		r.rate, r.burst = perSecond, float64(burst)
+	}
+}
+
+// WithSampleRate configures the probability, from 0 to 1, that an error is
+// considered for reporting at all. Defaults to 1, meaning every error is.
+func WithSampleRate(rate float64) RateLimitOption {
+	return func(r *RateLimiter) {
+		r.sampleRate = rate
+	}
+}
+
+// WithSummaryInterval configures how often summaries of suppressed errors are
+// reported. Defaults to one minute.
+func WithSummaryInterval(interval time.Duration) RateLimitOption {
+	return func(r *RateLimiter) {
+		r.summaryInterval = interval
+	}
+}
+
+// WithClock configures the function used to tell the time, which is
+// time.Now by default. This is useful for deterministic tests.
+func WithClock(now func() time.Time) RateLimitOption {
+	return func(r *RateLimiter) {
+		r.now = now
+	}
+}
+
+// WithRandomSource configures the function used for sampling, which must
+// return numbers in [0, 1). This is useful for deterministic tests.
+func WithRandomSource(random func() float64) RateLimitOption {
+	return func(r *RateLimiter) {
+		r.random = random
+	}
+}
+
+// NewRateLimiter creates a new RateLimiter that passes the errors it doesn't
+// suppress, and summaries of those it does, on to next.
+func NewRateLimiter(next ReportFunc, opts ...RateLimitOption) *RateLimiter {
+	r := &RateLimiter{
+		next:            next,
+		errors:          NewErrorHandler(WithStacktrace(false)),
+		rate:            defaultRatePerSecond,
+		burst:           defaultBurst,
+		sampleRate:      1,
+		summaryInterval: defaultSummaryInterval,
+		now:             time.Now,
+		random:          rand.Float64,
+		buckets:         map[string]*bucket{},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Report passes the given error on to the next ReportFunc, unless it is
+// sampled out or its fingerprint has exceeded the rate limit.
+// Any summaries that are due are reported first. Nil errors are ignored.
+func (r *RateLimiter) Report(ctx context.Context, err error) {
+	if err == nil {
+		return
+	}
+	fingerprint := Fingerprint(err)
+
+	r.mu.Lock()
+	now := r.now()
+	b, ok := r.buckets[fingerprint]
+	if !ok {
+		b = &bucket{tokens: r.burst, updated: now}
+		r.buckets[fingerprint] = b
+	}
+	allowed := r.allow(b, now)
+	if !allowed {
+		b.suppress(err, now)
+	}
+	var summaries []error
+	if now.Sub(r.lastSweep) >= r.summaryInterval {
+		summaries = r.summaries(now, false)
+		r.lastSweep = now
+	}
+	r.mu.Unlock()
+
+	for _, summary := range summaries {
+		r.next(ctx, summary)
+	}
+	if allowed {
+		r.next(ctx, err)
+	}
+}
+
+// Flush reports summaries of all errors suppressed since the last summary,
+// regardless of the summary interval.
+func (r *RateLimiter) Flush(ctx context.Context) {
+	r.mu.Lock()
+	summaries := r.summaries(r.now(), true)
+	r.mu.Unlock()
+
+	for _, summary := range summaries {
+		r.next(ctx, summary)
+	}
+}
+
+// allow refills the given bucket, and reports whether an error may be reported
+// by sampling it and taking a token from the bucket.
+func (r *RateLimiter) allow(b *bucket, now time.Time) bool {
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens = min(r.burst, b.tokens+elapsed*r.rate)
+		b.updated = now
+	}
+	if r.sampleRate < 1 && r.random() >= r.sampleRate {
+		return false
+	}
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// summaries returns summaries of the buckets with suppressed errors, and resets
+// them. Only buckets whose suppression started at least a summary interval
+// ago are summarised, unless all is set. Buckets that are idle are removed,
+// so that memory use is bounded by the number of recently seen fingerprints.
+func (r *RateLimiter) summaries(now time.Time, all bool) []error {
+	fingerprints := make([]string, 0, len(r.buckets))
+	for fingerprint := range r.buckets {
+		fingerprints = append(fingerprints, fingerprint)
+	}
+	sort.Strings(fingerprints)
+
+	summaries := []error{}
+	for _, fingerprint := range fingerprints {
+		b := r.buckets[fingerprint]
+		if b.suppressed == 0 {
+			if now.Sub(b.updated) >= r.summaryInterval {
+				delete(r.buckets, fingerprint)
+			}
+			continue
+		}
+		if !all && now.Sub(b.since) < r.summaryInterval {
+			continue
+		}
+		summaries = append(summaries, r.summary(fingerprint, b))
+		*b = bucket{tokens: b.tokens, updated: b.updated}
+	}
+	return summaries
+}
+
+// summary creates the summary error of the given bucket.
+func (r *RateLimiter) summary(fingerprint string, b *bucket) error {
+	metadata := make(map[string]interface{}, len(b.metadata)+3)
+	for k, values := range b.metadata {
+		if len(values) == 1 {
+			metadata[k] = values[0]
+		} else {
+			metadata[k] = values
+		}
+	}
+	metadata["suppressed.count"] = b.suppressed
+	metadata["suppressed.fingerprint"] = fingerprint
+	metadata["suppressed.since"] = b.since
+	ctx := WithMetadata(context.Background(), metadata)
+	return r.errors.Wrap(ctx, b.last, "%d similar errors suppressed", b.suppressed)
+}
+
+// suppress records that the given error was suppressed.
+// Valuers aren't resolved, as the values of suppressed errors are rarely
+// needed, and are left out of the summary instead.
+func (b *bucket) suppress(err error, now time.Time) {
+	if b.suppressed == 0 {
+		b.since = now
+		b.metadata = map[string][]interface{}{}
+	}
+	b.suppressed++
+	b.last = err
+	for k, v := range mergeMetadata(metadataLayers(err), MergeOutermostWins) {
+		if _, ok := v.(Valuer); ok {
+			continue
+		}
+		if len(b.metadata[k]) < maxSuppressedValues {
+			b.metadata[k] = appendDistinct(b.metadata[k], v)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package rogerr
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+type reported struct{ errs []error }
+
+func (r *reported) report(_ context.Context, err error) { r.errs = append(r.errs, err) }
+
+func TestRateLimiter(t *testing.T) {
+	h := NewErrorHandler(WithStacktrace(false))
+	newErr := func(id int) error {
+		return h.Wrap(WithMetadatum(context.Background(), "user.id", id), errors.New("db down"), "query failed")
+	}
+
+	t.Run("rate limits per fingerprint", func(t *testing.T) {
+		clock := &fakeClock{t: time.Unix(0, 0)}
+		got := &reported{}
+		r := NewRateLimiter(got.report, WithClock(clock.now), WithRateLimit(1, 2))
+
+		for i := 0; i < 5; i++ {
+			r.Report(t.Context(), newErr(i))
+		}
+		r.Report(t.Context(), errors.New("something else"))
+		if len(got.errs) != 3 {
+			t.Fatalf("expected 3 reported errors but got %d: %v", len(got.errs), got.errs)
+		}
+
+		clock.advance(time.Second)
+		r.Report(t.Context(), newErr(5))
+		r.Report(t.Context(), newErr(6))
+		if len(got.errs) != 4 {
+			t.Fatalf("expected the bucket to refill one token per second but got %d errors", len(got.errs))
+		}
+	})
+
+	t.Run("summarises suppressed errors", func(t *testing.T) {
+		clock := &fakeClock{t: time.Unix(0, 0)}
+		got := &reported{}
+		r := NewRateLimiter(got.report, WithClock(clock.now), WithRateLimit(0, 1), WithSummaryInterval(time.Minute))
+
+		for i := 0; i < 4; i++ {
+			r.Report(t.Context(), newErr(i%2))
+		}
+		if len(got.errs) != 1 {
+			t.Fatalf("expected 1 reported error but got %d", len(got.errs))
+		}
+
+		clock.advance(time.Minute)
+		r.Report(t.Context(), newErr(9))
+		if len(got.errs) != 2 {
+			t.Fatalf("expected a summary but got %d errors", len(got.errs))
+		}
+		summary := got.errs[1]
+		if exp := "4 similar errors suppressed: query failed: db down"; summary.Error() != exp {
+			t.Errorf("expected summary message '%s' but got '%s'", exp, summary.Error())
+		}
+		md := Metadata(summary)
+		if md["suppressed.count"] != 4 {
+			t.Errorf("expected suppressed.count 4 but got %v", md["suppressed.count"])
+		}
+		if md["suppressed.fingerprint"] != Fingerprint(newErr(0)) {
+			t.Errorf("expected the fingerprint of the suppressed errors but got %v", md["suppressed.fingerprint"])
+		}
+		if md["suppressed.since"] != time.Unix(0, 0) {
+			t.Errorf("expected suppressed.since to be the first suppression but got %v", md["suppressed.since"])
+		}
+		if exp := []interface{}{1, 0, 9}; !reflect.DeepEqual(md["user.id"], exp) {
+			t.Errorf("expected aggregated user.id %v but got %v", exp, md["user.id"])
+		}
+
+		r.Flush(t.Context())
+		if len(got.errs) != 2 {
+			t.Errorf("expected nothing to flush but got %v", got.errs)
+		}
+	})
+
+	t.Run("values of suppressed errors are not computed", func(t *testing.T) {
+		got := &reported{}
+		r := NewRateLimiter(got.report, WithRateLimit(0, 1))
+		lazyErr := func(compute func() any) error {
+			return h.Wrap(WithMetadatum(context.Background(), "db.stats", Lazy(compute)), errors.New("db down"), "query failed")
+		}
+
+		r.Report(t.Context(), lazyErr(func() any { return 1 }))
+		r.Report(t.Context(), lazyErr(func() any {
+			t.Error("expected the value of a suppressed error not to be computed")
+			return nil
+		}))
+		if len(got.errs) != 1 {
+			t.Fatalf("expected 1 reported error but got %d", len(got.errs))
+		}
+	})
+
+	t.Run("samples errors", func(t *testing.T) {
+		got := &reported{}
+		randoms := []float64{0.1, 0.9, 0.4, 0.6}
+		r := NewRateLimiter(got.report,
+			WithRateLimit(0, 100),
+			WithSampleRate(0.5),
+			WithRandomSource(func() float64 {
+				f := randoms[0]
+				randoms = randoms[1:]
+				return f
+			}),
+		)
+		for i := 0; i < 4; i++ {
+			r.Report(t.Context(), newErr(i))
+		}
+		if len(got.errs) != 2 {
+			t.Fatalf("expected 2 sampled errors but got %d", len(got.errs))
+		}
+		r.Flush(t.Context())
+		if len(got.errs) != 3 || Metadata(got.errs[2])["suppressed.count"] != 2 {
+			t.Errorf("expected sampled out errors to be summarised but got %v", got.errs)
+		}
+	})
+
+	t.Run("ignores nil errors", func(t *testing.T) {
+		got := &reported{}
+		r := NewRateLimiter(got.report)
+		r.Report(t.Context(), nil)
+		if len(got.errs) != 0 {
+			t.Errorf("expected no reported errors but got %v", got.errs)
+		}
+	})
+}
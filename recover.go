@@ -0,0 +1,64 @@
+package rogerr
+
+import (
+	"context"
+	"fmt"
+)
+
+// Recover converts a panic into a rogerr error, and assigns it to the error
+// that errPtr points to. It must be deferred directly:
+//
+//	func doWork(ctx context.Context) (err error) {
+//		defer handler.Recover(ctx, &err)
+//		...
+//	}
+//
+// The error's stacktrace starts at the frame that panicked rather than at the
+// deferred call, and the type of the panic value is attached as the
+// "panic.type" metadatum along with the metadata of the given ctx.
+// If the panic value is an error, it is wrapped by the returned error.
+// Does nothing if the goroutine isn't panicking.
+func (h *ErrorHandler) Recover(ctx context.Context, errPtr *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if errPtr == nil {
+		panic(r)
+	}
+	*errPtr = h.panicError(ctx, r)
+}
+
+// Go runs fn in a new goroutine, and sends its returned error on the returned
+// channel, which is then closed. If fn panics, the panic is converted into an
+// error as with Recover, so that panics are reported like any other error.
+func (h *ErrorHandler) Go(ctx context.Context, fn func(context.Context) error) <-chan error {
+	errs := make(chan error, 1)
+	go func() {
+		var err error
+		defer func() {
+			errs <- err
+			close(errs)
+		}()
+		defer h.Recover(ctx, &err)
+		err = fn(ctx)
+	}()
+	return errs
+}
+
+func (h *ErrorHandler) panicError(ctx context.Context, r interface{}) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	e := &rError{ctx: WithMetadatum(ctx, "panic.type", fmt.Sprintf("%T", r)), handler: h}
+	if err, ok := r.(error); ok {
+		e.err, e.msg = err, "panic"
+	} else {
+		e.msg = fmt.Sprintf("panic: %v", r)
+	}
+	if h.stacktrace {
+		e.pcs, e.truncated = callers(h.stack.capacity())
+		e.fromPanic = true
+	}
+	return e
+}
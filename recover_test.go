@@ -0,0 +1,108 @@
+package rogerr
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func panicker(v interface{}) {
+	panic(v)
+}
+
+func TestRecover(t *testing.T) {
+	handler := NewErrorHandler()
+	ctx := WithMetadatum(context.Background(), "userID", 123)
+
+	recovered := func(v interface{}) (err error) {
+		defer handler.Recover(ctx, &err)
+		panicker(v)
+		return nil
+	}
+
+	t.Run("non-error panic value", func(t *testing.T) {
+		err := recovered("something bad")
+		if exp := "panic: something bad"; err == nil || err.Error() != exp {
+			t.Fatalf("expected error %q but got %v", exp, err)
+		}
+		md := Metadata(err)
+		if md["panic.type"] != "string" || md["userID"] != 123 {
+			t.Errorf("unexpected metadata %v", md)
+		}
+	})
+
+	t.Run("error panic value is wrapped", func(t *testing.T) {
+		cause := errors.New("boom")
+		err := recovered(cause)
+		if !errors.Is(err, cause) {
+			t.Errorf("expected error to wrap the panic value but got %v", err)
+		}
+		if exp := "panic: boom"; err.Error() != exp {
+			t.Errorf("expected error %q but got %q", exp, err.Error())
+		}
+	})
+
+	t.Run("stacktrace starts at the panicking frame", func(t *testing.T) {
+		frames := handler.Stacktrace(recovered("oops"))
+		if len(frames) == 0 || frames[0].Function != "github.com/kinbiko/rogerr.panicker" {
+			t.Errorf("expected stacktrace to start at panicker but got %+v", frames)
+		}
+	})
+
+	t.Run("runtime errors skip runtime frames", func(t *testing.T) {
+		var err error
+		func() {
+			defer handler.Recover(ctx, &err)
+			var m map[string]int
+			m["key"]++ //nolint:staticcheck // intentionally panicking
+		}()
+		frames := handler.Stacktrace(err)
+		if len(frames) == 0 || strings.HasPrefix(frames[0].Function, "runtime.") {
+			t.Errorf("expected stacktrace to start outside the runtime but got %+v", frames)
+		}
+		if exp := "panic: assignment to entry in nil map"; err.Error() != exp {
+			t.Errorf("expected error %q but got %q", exp, err.Error())
+		}
+	})
+
+	t.Run("no panic", func(t *testing.T) {
+		err := func() (err error) {
+			defer handler.Recover(ctx, &err)
+			return nil
+		}()
+		if err != nil {
+			t.Errorf("expected no error but got %v", err)
+		}
+	})
+}
+
+func TestGo(t *testing.T) {
+	handler := NewErrorHandler()
+
+	t.Run("returned error", func(t *testing.T) {
+		exp := errors.New("oops")
+		if err := <-handler.Go(context.Background(), func(context.Context) error { return exp }); err != exp {
+			t.Errorf("expected %v but got %v", exp, err)
+		}
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		ctx := WithMetadatum(context.Background(), "jobID", "abc")
+		err := <-handler.Go(ctx, func(context.Context) error { panicker("oops"); return nil })
+		if err == nil || err.Error() != "panic: oops" {
+			t.Fatalf("expected panic error but got %v", err)
+		}
+		if got := Metadata(err)["jobID"]; got != "abc" {
+			t.Errorf("expected ctx metadata to be attached but got %v", got)
+		}
+	})
+
+	t.Run("channel is closed", func(t *testing.T) {
+		errs := handler.Go(context.Background(), func(context.Context) error { return nil })
+		<-errs
+		if _, ok := <-errs; ok {
+			t.Error("expected channel to be closed")
+		}
+	})
+}
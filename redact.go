@@ -0,0 +1,167 @@
+package rogerr
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"regexp"
+)
+
+// Sensitive is a marker interface for metadata values that must never leave
+// the process in plain text. Such values are always redacted, regardless of
+// their key.
+type Sensitive interface {
+	Sensitive()
+}
+
+// redactor removes or hashes sensitive metadata.
+type redactor struct {
+	allowed  []string
+	denied   []string
+	patterns []*regexp.Regexp
+	hash     bool
+}
+
+// WithRedactedKeys configures metadata keys to redact.
+// Keys may be given exactly or as path.Match glob patterns, e.g. "*_token".
+func WithRedactedKeys(patterns ...string) Option {
+	return func(h *ErrorHandler) {
+		h.redaction.denied = append(h.redaction.denied, patterns...)
+	}
+}
+
+// WithRedactedKeyPattern configures metadata keys matching the given regular expression to be redacted.
+func WithRedactedKeyPattern(re *regexp.Regexp) Option {
+	return func(h *ErrorHandler) {
+		h.redaction.patterns = append(h.redaction.patterns, re)
+	}
+}
+
+// WithAllowedKeys configures the only metadata keys that are not redacted.
+// Keys may be given exactly or as path.Match glob patterns, e.g. "http.*".
+// Keys that are allowed are still redacted if they are also configured to
+// be redacted, or if their value is Sensitive.
+func WithAllowedKeys(patterns ...string) Option {
+	return func(h *ErrorHandler) {
+		h.redaction.allowed = append(h.redaction.allowed, patterns...)
+	}
+}
+
+// WithRedactionHashing configures whether redacted metadata values are
+// replaced with a hash of the value rather than removed entirely.
+// Hashing keeps values correlatable across errors without revealing them,
+// but note that low-entropy values such as small numbers can be guessed.
+func WithRedactionHashing(enabled bool) Option {
+	return func(h *ErrorHandler) {
+		h.redaction.hash = enabled
+	}
+}
+
+// ContextMetadata returns a copy of the rogerr metadata associated with the
+// given context, redacted according to the handler's configuration.
+// Returns nil if the given ctx was nil.
+func (h *ErrorHandler) ContextMetadata(ctx context.Context) map[string]interface{} {
+	return h.redaction.redact(getMetadata(ctx))
+}
+
+// SelectContextMetadata is like ContextMetadata, except that only the keys
+// for which keep returns true are included. The values of other keys are
+// never resolved, so Lazy values that aren't needed are never computed.
+func (h *ErrorHandler) SelectContextMetadata(ctx context.Context, keep func(key string) bool) map[string]interface{} {
+	md := getMetadata(ctx)
+	for k := range md {
+		if !keep(k) {
+			delete(md, k)
+		}
+	}
+	return h.redaction.redact(md)
+}
+
+// redact removes or hashes the sensitive entries of the given metadata, in place.
+// Valuers are resolved, unless their key is redacted, in which case their
+// value is only needed for hashing.
+func (r *redactor) redact(md map[string]interface{}) map[string]interface{} {
+	return r.apply(md, true)
+}
+
+// redactLayer is like redact, except that the values that aren't redacted are
+// left unresolved. It applies the rules of the handler that created a layer
+// of an error, before the metadata is redacted again by the handler that
+// exports it.
+func (r *redactor) redactLayer(md map[string]interface{}) map[string]interface{} {
+	return r.apply(md, false)
+}
+
+func (r *redactor) apply(md map[string]interface{}, resolveValues bool) map[string]interface{} {
+	for k, v := range md {
+		if !r.sensitiveKey(k) && !isSensitive(v) {
+			if !resolveValues {
+				continue
+			}
+			if v = resolveKeptValues(v); !isSensitive(v) {
+				md[k] = v
+				continue
+			}
+		}
+		if r.hash {
+			sum := sha256.Sum256([]byte(fmt.Sprintf("%v", resolveKeptValues(v))))
+			md[k] = "sha256:" + hex.EncodeToString(sum[:8])
+		} else {
+			delete(md, k)
+		}
+	}
+	return md
+}
+
+// resolveKeptValues is like resolve, except that the values kept by
+// MergeKeepAll are resolved individually, dropping any duplicates this reveals.
+func resolveKeptValues(v interface{}) interface{} {
+	kept, ok := v.(keptValues)
+	if !ok {
+		return resolve(v)
+	}
+	values := []interface{}{}
+	for _, value := range kept {
+		values = appendDistinct(values, resolve(value))
+	}
+	return values
+}
+
+func isSensitive(value interface{}) bool {
+	if kept, ok := value.([]interface{}); ok {
+		for _, v := range kept {
+			if isSensitive(v) {
+				return true
+			}
+		}
+		return false
+	}
+	_, ok := value.(Sensitive)
+	return ok
+}
+
+func (r *redactor) sensitiveKey(key string) bool {
+	if len(r.allowed) > 0 && !matchesAny(r.allowed, key) {
+		return true
+	}
+	if matchesAny(r.denied, key) {
+		return true
+	}
+	for _, re := range r.patterns {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAny(patterns []string, key string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,189 @@
+package rogerr_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/kinbiko/rogerr"
+)
+
+type password string
+
+func (password) Sensitive() {}
+
+func TestRedaction(t *testing.T) {
+	ctx := rogerr.WithMetadata(context.Background(), map[string]interface{}{
+		"userID":        123,
+		"email":         "someone@example.com",
+		"access_token":  "abc",
+		"refresh_token": "def",
+		"password":      password("hunter2"),
+		"http.method":   "GET",
+	})
+
+	for name, tc := range map[string]struct {
+		opts []rogerr.Option
+		exp  map[string]interface{}
+	}{
+		"sensitive values are always redacted": {
+			opts: nil,
+			exp:  map[string]interface{}{"userID": 123, "email": "someone@example.com", "access_token": "abc", "refresh_token": "def", "http.method": "GET"},
+		},
+		"deny-list with exact keys and globs": {
+			opts: []rogerr.Option{rogerr.WithRedactedKeys("email", "*_token")},
+			exp:  map[string]interface{}{"userID": 123, "http.method": "GET"},
+		},
+		"regexp key pattern": {
+			opts: []rogerr.Option{rogerr.WithRedactedKeyPattern(regexp.MustCompile(`(?i)^(email|access_token)$`))},
+			exp:  map[string]interface{}{"userID": 123, "refresh_token": "def", "http.method": "GET"},
+		},
+		"allow-list": {
+			opts: []rogerr.Option{rogerr.WithAllowedKeys("userID", "http.*", "password")},
+			exp:  map[string]interface{}{"userID": 123, "http.method": "GET"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got := rogerr.Metadata(rogerr.NewErrorHandler(tc.opts...).Wrap(ctx, nil, "oops")); !reflect.DeepEqual(got, tc.exp) {
+				t.Errorf("expected %v but got %v", tc.exp, got)
+			}
+		})
+	}
+
+	t.Run("hashing instead of removing", func(t *testing.T) {
+		handler := rogerr.NewErrorHandler(rogerr.WithRedactedKeys("email"), rogerr.WithRedactionHashing(true))
+		got := rogerr.Metadata(handler.Wrap(ctx, nil, "oops"))
+		for _, k := range []string{"email", "password"} {
+			if s, ok := got[k].(string); !ok || !strings.HasPrefix(s, "sha256:") || len(s) != len("sha256:")+16 {
+				t.Errorf("expected hashed value at key '%s' but got %v", k, got[k])
+			}
+		}
+		if again := rogerr.Metadata(handler.Wrap(ctx, nil, "again")); got["email"] != again["email"] {
+			t.Errorf("expected hashes to be stable but got %v and %v", got["email"], again["email"])
+		}
+		if got["userID"] != 123 {
+			t.Errorf("expected other values to be untouched but got %v", got)
+		}
+	})
+}
+
+func TestRedactionExportPaths(t *testing.T) {
+	handler := rogerr.NewErrorHandler(rogerr.WithRedactedKeys("email"))
+	ctx := rogerr.WithMetadata(context.Background(), map[string]interface{}{"email": "someone@example.com", "userID": 123})
+	err := handler.Wrap(ctx, nil, "oops")
+	secret := "someone@example.com"
+
+	t.Run("JSON", func(t *testing.T) {
+		data, _ := json.Marshal(err)
+		if bytes.Contains(data, []byte(secret)) {
+			t.Errorf("expected JSON to be redacted but got %s", data)
+		}
+	})
+
+	t.Run("slog", func(t *testing.T) {
+		var buf bytes.Buffer
+		slog.New(slog.NewJSONHandler(&buf, nil)).Error("failed", slog.Any("err", err))
+		if bytes.Contains(buf.Bytes(), []byte(secret)) {
+			t.Errorf("expected log output to be redacted but got %s", buf.String())
+		}
+	})
+
+	t.Run("formatter", func(t *testing.T) {
+		if got := fmt.Sprintf("%+v", err); strings.Contains(got, secret) {
+			t.Errorf("expected verbose output to be redacted but got %s", got)
+		}
+	})
+
+	t.Run("per-layer breakdown", func(t *testing.T) {
+		if got := rogerr.MetadataLayers(err)[0].Metadata; got["email"] != nil {
+			t.Errorf("expected layer metadata to be redacted but got %v", got)
+		}
+	})
+
+	t.Run("context metadata", func(t *testing.T) {
+		if got := handler.ContextMetadata(ctx); got["email"] != nil || got["userID"] != 123 {
+			t.Errorf("expected context metadata to be redacted but got %v", got)
+		}
+		keep := func(string) bool { return true }
+		if got := handler.SelectContextMetadata(ctx, keep); got["email"] != nil || got["userID"] != 123 {
+			t.Errorf("expected selected context metadata to be redacted but got %v", got)
+		}
+		if got := handler.SelectContextMetadata(ctx, func(k string) bool { return k == "email" }); len(got) != 0 {
+			t.Errorf("expected only the selected keys but got %v", got)
+		}
+	})
+}
+
+func TestRedactionByExportingHandler(t *testing.T) {
+	secret := "someone@example.com"
+	ctx := rogerr.WithMetadata(context.Background(), map[string]interface{}{"email": secret, "userID": 123})
+	ctx = rogerr.Breadcrumb(ctx, "auth", "login", map[string]interface{}{"email": secret})
+	err := rogerr.NewErrorHandler().Wrap(ctx, nil, "oops")
+	handler := rogerr.NewErrorHandler(rogerr.WithRedactedKeys("email"))
+
+	t.Run("metadata", func(t *testing.T) {
+		if got := handler.Metadata(err); got["email"] != nil || got["userID"] != 123 {
+			t.Errorf("expected metadata to be redacted by the exporting handler but got %v", got)
+		}
+	})
+
+	t.Run("breadcrumbs", func(t *testing.T) {
+		if got := handler.Breadcrumbs(err); len(got) != 1 || got[0].Data["email"] != nil {
+			t.Errorf("expected breadcrumbs to be redacted by the exporting handler but got %v", got)
+		}
+	})
+
+	t.Run("report", func(t *testing.T) {
+		var ev *rogerr.Event
+		h := rogerr.NewErrorHandler(rogerr.WithRedactedKeys("email"), rogerr.WithSinks(rogerr.SinkFunc(func(_ context.Context, e *rogerr.Event) error {
+			ev = e
+			return nil
+		})))
+		h.Report(context.Background(), err)
+
+		data, _ := json.Marshal(ev)
+		if ev.Metadata["email"] != nil || bytes.Contains(data, []byte(secret)) {
+			t.Errorf("expected event to be redacted by the reporting handler but got %s", data)
+		}
+	})
+
+	t.Run("kept values", func(t *testing.T) {
+		h := rogerr.NewErrorHandler(rogerr.WithMergeStrategy(rogerr.MergeKeepAll), rogerr.WithRedactionHashing(true))
+		inner := rogerr.NewErrorHandler().Wrap(rogerr.WithMetadatum(ctx, "token", rogerr.Lazy(func() any { return password("a") })), nil, "inner")
+		outer := rogerr.NewErrorHandler().Wrap(rogerr.WithMetadatum(ctx, "token", "b"), inner, "outer")
+		if got, ok := h.Metadata(outer)["token"].(string); !ok || !strings.HasPrefix(got, "sha256:") {
+			t.Errorf("expected kept values that resolve to a sensitive value to be hashed but got %v", h.Metadata(outer)["token"])
+		}
+	})
+}
+
+func TestRedactionByCreatingHandler(t *testing.T) {
+	secret := "s3cr3t"
+	library := rogerr.NewErrorHandler(rogerr.WithRedactedKeys("token"))
+	ctx := rogerr.WithMetadata(context.Background(), map[string]interface{}{"token": secret, "userID": 123})
+	ctx = rogerr.Breadcrumb(ctx, "auth", "login", map[string]interface{}{"token": secret})
+	err := rogerr.NewErrorHandler().Wrap(context.Background(), library.Wrap(ctx, nil, "library"), "app")
+
+	for name, got := range map[string]interface{}{
+		"Metadata":        rogerr.Metadata(err),
+		"handler":         rogerr.NewErrorHandler().Metadata(err),
+		"MetadataLayers":  rogerr.MetadataLayers(err),
+		"Breadcrumbs":     rogerr.Breadcrumbs(err),
+		"formatter":       fmt.Sprintf("%+v", err),
+		"JSON":            func() string { data, _ := json.Marshal(err); return string(data) }(),
+		"NestedMetadata":  rogerr.NestedMetadata(err),
+		"with other keys": rogerr.NewErrorHandler(rogerr.WithRedactedKeys("userID")).Metadata(err),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if s := fmt.Sprintf("%v", got); strings.Contains(s, secret) {
+				t.Errorf("expected the creating handler's redaction to apply but got %s", s)
+			}
+		})
+	}
+}
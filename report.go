@@ -0,0 +1,301 @@
+package rogerr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ErrBufferFull is passed to the sink error handler when an event is dropped
+// because the buffer of an ErrorHandler configured with WithAsyncReporting is full.
+var ErrBufferFull = errors.New("rogerr: report buffer full")
+
+// ErrClosed is passed to the sink error handler when an error is reported to
+// an ErrorHandler that has been closed.
+var ErrClosed = errors.New("rogerr: error handler closed")
+
+// Reporter reports errors, e.g. by logging them or by sending them to an
+// error tracking service. ErrorHandler, RateLimiter, and ReportFunc are Reporters.
+type Reporter interface {
+	Report(ctx context.Context, err error)
+}
+
+// Report calls f(ctx, err).
+func (f ReportFunc) Report(ctx context.Context, err error) {
+	f(ctx, err)
+}
+
+// Event is a reported error, as it is passed to sinks.
+type Event struct {
+	Time        time.Time              // When the error was reported
+	Err         error                  // The reported error
+	Message     string                 // The error message
+	Code        Code                   // The Code of the error
+	Fingerprint string                 // The Fingerprint of the error
+	Metadata    map[string]interface{} // The metadata of the error and the context it was reported with
+	Stacktrace  []Frame                // The stacktrace of the error
+	Breadcrumbs []BreadcrumbEntry      // The breadcrumbs of the error and the context it was reported with
+
+	handler *ErrorHandler // The handler that reported the error
+}
+
+// MarshalJSON implements json.Marshaler.
+// The error is included in the same form as when marshalling a rogerr error,
+// redacted according to the ErrorHandler that reported it, and metadata
+// values that cannot be marshalled are represented by their %v string instead.
+func (e *Event) MarshalJSON() ([]byte, error) {
+	var errJSON *errorJSON
+	if e.Err != nil {
+		h := e.handler
+		if h == nil {
+			h = exportHandler(e.Err)
+		}
+		errJSON = toErrorJSON(e.Err, h)
+	}
+	return json.Marshal(struct {
+		Time        time.Time              `json:"time"`
+		Message     string                 `json:"message"`
+		Code        string                 `json:"code"`
+		Fingerprint string                 `json:"fingerprint"`
+		Metadata    map[string]interface{} `json:"metadata,omitempty"`
+		Stacktrace  []Frame                `json:"stacktrace,omitempty"`
+		Breadcrumbs []BreadcrumbEntry      `json:"breadcrumbs,omitempty"`
+		Error       *errorJSON             `json:"error,omitempty"`
+	}{
+		Time:        e.Time,
+		Message:     e.Message,
+		Code:        e.Code.String(),
+		Fingerprint: e.Fingerprint,
+		Metadata:    JSONSafe(e.Metadata),
+		Stacktrace:  e.Stacktrace,
+		Breadcrumbs: jsonSafeBreadcrumbs(e.Breadcrumbs),
+		Error:       errJSON,
+	})
+}
+
+// Sink receives the events of reported errors.
+type Sink interface {
+	Send(ctx context.Context, event *Event) error
+}
+
+// SinkFunc is an adapter that allows ordinary functions to be used as Sinks.
+type SinkFunc func(ctx context.Context, event *Event) error
+
+// Send calls f(ctx, event).
+func (f SinkFunc) Send(ctx context.Context, event *Event) error {
+	return f(ctx, event)
+}
+
+// BeforeSendFunc is called with every event before it is passed to sinks.
+// It may modify the event, or return nil to drop it.
+type BeforeSendFunc func(ctx context.Context, event *Event) *Event
+
+// SinkErrorFunc is called when an event couldn't be passed to a sink.
+type SinkErrorFunc func(ctx context.Context, event *Event, err error)
+
+// pipeline holds the configuration and state of the reporting of an ErrorHandler.
+type pipeline struct {
+	sinks      []Sink
+	beforeSend []BeforeSendFunc
+	onError    SinkErrorFunc
+	buffer     int
+
+	start  sync.Once
+	mu     sync.RWMutex
+	closed bool
+	queue  chan queued
+	done   chan struct{}
+}
+
+// queued is an event waiting to be sent by an asynchronous pipeline, or a
+// flush marker, whose channel is closed once every earlier event is sent.
+type queued struct {
+	ctx     context.Context //nolint:containedctx // carried to the sinks along with the event
+	event   *Event
+	flushed chan struct{}
+}
+
+// WithSinks configures sinks that the events of reported errors are passed to.
+func WithSinks(sinks ...Sink) Option {
+	return func(h *ErrorHandler) {
+		h.reporting.sinks = append(h.reporting.sinks, sinks...)
+	}
+}
+
+// WithBeforeSend configures functions that are called in order with every
+// event before it is passed to sinks, and which may modify or drop it.
+func WithBeforeSend(hooks ...BeforeSendFunc) Option {
+	return func(h *ErrorHandler) {
+		h.reporting.beforeSend = append(h.reporting.beforeSend, hooks...)
+	}
+}
+
+// WithAsyncReporting configures events to be passed to sinks by a background
+// goroutine, so that Report doesn't block on slow sinks. Up to bufferSize
+// events are buffered, and events reported while the buffer is full are
+// dropped. Use Flush and Close to wait for buffered events to be sent.
+func WithAsyncReporting(bufferSize int) Option {
+	return func(h *ErrorHandler) {
+		h.reporting.buffer = bufferSize
+	}
+}
+
+// WithSinkErrorHandler configures the function that is called when an event
+// couldn't be passed to a sink. By default, such errors are logged with the
+// default log/slog logger.
+func WithSinkErrorHandler(fn SinkErrorFunc) Option {
+	return func(h *ErrorHandler) {
+		h.reporting.onError = fn
+	}
+}
+
+// Report passes an event of the given error to the configured sinks, after
+// it has been through the configured WithBeforeSend functions. The event's
+// metadata includes the metadata of the given ctx, although the error's own
+// metadata takes precedence, and likewise for breadcrumbs. Nil errors are ignored.
+func (h *ErrorHandler) Report(ctx context.Context, err error) {
+	if err == nil {
+		return
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	event := h.event(ctx, err)
+	for _, hook := range h.reporting.beforeSend {
+		if event = hook(ctx, event); event == nil {
+			return
+		}
+	}
+
+	p := &h.reporting
+	if p.buffer <= 0 {
+		p.send(ctx, event)
+		return
+	}
+	p.start.Do(p.run)
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		p.handleError(ctx, event, ErrClosed)
+		return
+	}
+	select {
+	case p.queue <- queued{ctx: context.WithoutCancel(ctx), event: event}:
+	default:
+		p.handleError(ctx, event, ErrBufferFull)
+	}
+}
+
+// Flush waits until every event reported before the call has been passed to
+// the sinks, or until the given ctx is done.
+// Does nothing unless configured with WithAsyncReporting.
+func (h *ErrorHandler) Flush(ctx context.Context) error {
+	p := &h.reporting
+	p.mu.RLock()
+	if p.queue == nil || p.closed {
+		p.mu.RUnlock()
+		return nil
+	}
+	flushed := make(chan struct{})
+	select {
+	case p.queue <- queued{flushed: flushed}:
+		p.mu.RUnlock()
+	case <-ctx.Done():
+		p.mu.RUnlock()
+		return ctx.Err()
+	}
+
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close waits until every buffered event has been passed to the sinks, and
+// stops the background goroutine. Errors reported afterwards are dropped.
+// Does nothing unless configured with WithAsyncReporting.
+func (h *ErrorHandler) Close() error {
+	p := &h.reporting
+	p.mu.Lock()
+	if p.queue == nil || p.closed {
+		p.closed = true
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.queue)
+	p.mu.Unlock()
+
+	<-p.done
+	return nil
+}
+
+// event creates the event of the given error.
+func (h *ErrorHandler) event(ctx context.Context, err error) *Event {
+	metadata := h.ContextMetadata(ctx)
+	for k, v := range h.Metadata(err) {
+		if metadata == nil {
+			metadata = map[string]interface{}{}
+		}
+		metadata[k] = v
+	}
+	return &Event{
+		Time:        time.Now(),
+		Err:         err,
+		Message:     err.Error(),
+		Code:        CodeOf(err),
+		Fingerprint: Fingerprint(err),
+		Metadata:    metadata,
+		Stacktrace:  h.Stacktrace(err),
+		Breadcrumbs: mergeBreadcrumbs([][]BreadcrumbEntry{
+			h.breadcrumbs(getCtxData(ctx).breadcrumbs, map[*breadcrumbNode]bool{}, nil),
+			h.Breadcrumbs(err),
+		}),
+		handler: h,
+	}
+}
+
+// run starts the background goroutine of an asynchronous pipeline.
+func (p *pipeline) run() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return
+	}
+	p.queue = make(chan queued, p.buffer)
+	p.done = make(chan struct{})
+
+	go func() {
+		defer close(p.done)
+		for q := range p.queue {
+			if q.flushed != nil {
+				close(q.flushed)
+				continue
+			}
+			p.send(q.ctx, q.event)
+		}
+	}()
+}
+
+// send passes the given event to every sink.
+func (p *pipeline) send(ctx context.Context, event *Event) {
+	for _, sink := range p.sinks {
+		if err := sink.Send(ctx, event); err != nil {
+			p.handleError(ctx, event, err)
+		}
+	}
+}
+
+func (p *pipeline) handleError(ctx context.Context, event *Event, err error) {
+	if p.onError != nil {
+		p.onError(ctx, event, err)
+		return
+	}
+	slog.ErrorContext(ctx, "unable to report error", slog.String("error", event.Message), slog.Any("cause", err))
+}
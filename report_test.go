@@ -0,0 +1,227 @@
+package rogerr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingSink struct {
+	mu     sync.Mutex
+	events []*Event
+	err    error
+	block  chan struct{}
+}
+
+func (s *recordingSink) Send(_ context.Context, event *Event) error {
+	if s.block != nil {
+		<-s.block
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	return s.err
+}
+
+func (s *recordingSink) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.events)
+}
+
+func TestReport(t *testing.T) {
+	ctx := WithMetadata(context.Background(), map[string]interface{}{"request.id": "abc", "user.id": 1})
+
+	t.Run("fans out events to every sink", func(t *testing.T) {
+		a, b := &recordingSink{}, &recordingSink{}
+		h := NewErrorHandler(WithSinks(a, b))
+		err := h.Wrap(WithCode(WithMetadatum(ctx, "user.id", 2), CodeNotFound), errors.New("no rows"), "user not found")
+		h.Report(ctx, err)
+
+		if a.count() != 1 || b.count() != 1 {
+			t.Fatalf("expected one event in each sink but got %d and %d", a.count(), b.count())
+		}
+		ev := a.events[0]
+		if ev.Err != err || ev.Message != "user not found: no rows" || ev.Code != CodeNotFound {
+			t.Errorf("unexpected event: %+v", ev)
+		}
+		if ev.Fingerprint != Fingerprint(err) || len(ev.Stacktrace) == 0 || ev.Time.IsZero() {
+			t.Errorf("unexpected event: %+v", ev)
+		}
+		if exp := map[string]interface{}{"request.id": "abc", "user.id": 2}; !reflect.DeepEqual(ev.Metadata, exp) {
+			t.Errorf("expected metadata %v but got %v", exp, ev.Metadata)
+		}
+	})
+
+	t.Run("includes the breadcrumbs of the error and the context", func(t *testing.T) {
+		s := &recordingSink{}
+		h := NewErrorHandler(WithSinks(s))
+		ctx := Breadcrumb(ctx, "app", "first", nil)
+		err := h.Wrap(ctx, nil, "oops")
+		h.Report(Breadcrumb(ctx, "app", "second", nil), err)
+		if got := messages(s.events[0].Breadcrumbs); !reflect.DeepEqual(got, []string{"first", "second"}) {
+			t.Errorf("expected [first second] but got %v", got)
+		}
+	})
+
+	t.Run("ignores nil errors", func(t *testing.T) {
+		s := &recordingSink{}
+		NewErrorHandler(WithSinks(s)).Report(ctx, nil)
+		if s.count() != 0 {
+			t.Errorf("expected no events but got %d", s.count())
+		}
+	})
+
+	t.Run("before send hooks", func(t *testing.T) {
+		s := &recordingSink{}
+		h := NewErrorHandler(WithSinks(s), WithBeforeSend(
+			func(_ context.Context, ev *Event) *Event {
+				delete(ev.Metadata, "user.id")
+				return ev
+			},
+			func(_ context.Context, ev *Event) *Event {
+				if ev.Code == CodeCanceled {
+					return nil
+				}
+				return ev
+			},
+		))
+		h.Report(ctx, errors.New("oops"))
+		h.Report(WithCode(ctx, CodeCanceled), h.Wrap(WithCode(ctx, CodeCanceled), nil, "canceled"))
+
+		if s.count() != 1 {
+			t.Fatalf("expected 1 event but got %d", s.count())
+		}
+		if exp := map[string]interface{}{"request.id": "abc"}; !reflect.DeepEqual(s.events[0].Metadata, exp) {
+			t.Errorf("expected metadata %v but got %v", exp, s.events[0].Metadata)
+		}
+	})
+
+	t.Run("sink errors", func(t *testing.T) {
+		sinkErr := errors.New("sink down")
+		failing, ok := &recordingSink{err: sinkErr}, &recordingSink{}
+		var got []error
+		h := NewErrorHandler(WithSinks(failing, ok), WithSinkErrorHandler(func(_ context.Context, _ *Event, err error) {
+			got = append(got, err)
+		}))
+		h.Report(ctx, errors.New("oops"))
+		if len(got) != 1 || !errors.Is(got[0], sinkErr) {
+			t.Errorf("expected the sink error to be handled but got %v", got)
+		}
+		if ok.count() != 1 {
+			t.Error("expected a failing sink not to stop other sinks")
+		}
+	})
+
+	t.Run("async", func(t *testing.T) {
+		s := &recordingSink{block: make(chan struct{})}
+		var mu sync.Mutex
+		var got []error
+		h := NewErrorHandler(WithSinks(s), WithAsyncReporting(1), WithSinkErrorHandler(func(_ context.Context, _ *Event, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, err)
+		}))
+
+		h.Report(ctx, errors.New("first"))
+		waitUntil(t, func() bool { return len(h.reporting.queue) == 0 })
+		h.Report(ctx, errors.New("second"))
+		h.Report(ctx, errors.New("dropped"))
+		mu.Lock()
+		if len(got) != 1 || !errors.Is(got[0], ErrBufferFull) {
+			t.Errorf("expected ErrBufferFull but got %v", got)
+		}
+		mu.Unlock()
+
+		timeout, cancel := context.WithTimeout(ctx, time.Millisecond)
+		defer cancel()
+		if err := h.Flush(timeout); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected Flush to time out while the sink is blocked but got %v", err)
+		}
+
+		close(s.block)
+		if err := h.Flush(ctx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if s.count() != 2 {
+			t.Errorf("expected 2 events after flushing but got %d", s.count())
+		}
+
+		h.Report(ctx, errors.New("third"))
+		if err := h.Close(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if s.count() != 3 {
+			t.Errorf("expected 3 events after closing but got %d", s.count())
+		}
+		h.Report(ctx, errors.New("after close"))
+		if len(got) != 2 || !errors.Is(got[1], ErrClosed) {
+			t.Errorf("expected ErrClosed but got %v", got)
+		}
+		if err := h.Flush(ctx); err != nil {
+			t.Errorf("expected Flush after Close to do nothing but got %v", err)
+		}
+	})
+
+	t.Run("flush and close without async reporting", func(t *testing.T) {
+		h := NewErrorHandler()
+		if err := h.Flush(ctx); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if err := h.Close(); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestReportFunc(t *testing.T) {
+	var got error
+	var r Reporter = ReportFunc(func(_ context.Context, err error) { got = err })
+	err := errors.New("oops")
+	r.Report(context.Background(), err)
+	if got != err {
+		t.Errorf("expected the reported error to be passed on but got %v", got)
+	}
+}
+
+func TestEventMarshalJSON(t *testing.T) {
+	h := NewErrorHandler(WithStacktrace(false))
+	ev := &Event{
+		Time:        time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Err:         h.Wrap(context.Background(), errors.New("no rows"), "user not found"),
+		Message:     "user not found: no rows",
+		Code:        CodeNotFound,
+		Fingerprint: "abc",
+		Metadata:    map[string]interface{}{"fn": func() {}},
+	}
+	data, err := json.Marshal(ev)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got["time"] != "2024-01-02T03:04:05Z" || got["code"] != "not_found" || got["fingerprint"] != "abc" {
+		t.Errorf("unexpected JSON: %s", data)
+	}
+	if e, _ := got["error"].(map[string]interface{}); e["wrapMessage"] != "user not found" {
+		t.Errorf("expected the error to be included but got %s", data)
+	}
+	if md, _ := got["metadata"].(map[string]interface{}); md["fn"] == nil {
+		t.Errorf("expected unmarshalable metadata to be stringified but got %s", data)
+	}
+}
+
+func waitUntil(t *testing.T, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); !cond(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+	}
+}
@@ -0,0 +1,132 @@
+/*
+Package sentry encodes rogerr errors as Sentry events, and sends them to a
+Sentry-compatible ingest endpoint, without depending on the Sentry SDK.
+
+	transport, err := sentry.NewTransport(dsn)
+	...
+	ev := sentry.NewEvent(handler, err)
+	if err := transport.Send(ctx, ev); err != nil {
+		...
+	}
+*/
+package sentry
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/kinbiko/rogerr"
+)
+
+// Event is a Sentry error event.
+type Event struct {
+	EventID     string                 `json:"event_id"`
+	Timestamp   time.Time              `json:"timestamp"`
+	Platform    string                 `json:"platform"`
+	Level       string                 `json:"level"`
+	Exception   *Exceptions            `json:"exception,omitempty"`
+	Extra       map[string]interface{} `json:"extra,omitempty"`
+	Fingerprint []string               `json:"fingerprint,omitempty"`
+	Breadcrumbs *Breadcrumbs           `json:"breadcrumbs,omitempty"`
+}
+
+// Breadcrumbs holds the breadcrumbs of an event, ordered from the oldest to
+// the most recent.
+type Breadcrumbs struct {
+	Values []Breadcrumb `json:"values"`
+}
+
+// Breadcrumb is a single event that happened before the error.
+type Breadcrumb struct {
+	Timestamp time.Time              `json:"timestamp"`
+	Type      string                 `json:"type"`
+	Category  string                 `json:"category"`
+	Message   string                 `json:"message"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+}
+
+// Exceptions holds the exceptions of an event, ordered from the root cause
+// to the outermost error.
+type Exceptions struct {
+	Values []Exception `json:"values"`
+}
+
+// Exception is a single error in the cause chain of an event.
+type Exception struct {
+	Type       string      `json:"type"`
+	Value      string      `json:"value"`
+	Stacktrace *Stacktrace `json:"stacktrace,omitempty"`
+}
+
+// Stacktrace holds the frames of an exception, ordered from the oldest
+// caller to the frame where the error was wrapped.
+type Stacktrace struct {
+	Frames []Frame `json:"frames"`
+}
+
+// Frame is a single stacktrace frame of an exception.
+type Frame struct {
+	Function string `json:"function"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+// NewEvent creates a Sentry event from the given error.
+// The exceptions are taken from the error's cause chain, with the stacktrace
+// attached to the outermost exception. The error's metadata is sent as
+// extra data, its rogerr.Breadcrumbs as breadcrumbs, and its
+// rogerr.Fingerprint is used to group events.
+// The handler determines how metadata and stacktraces are extracted, and
+// may be nil to use the default rogerr.ErrorHandler.
+func NewEvent(handler *rogerr.ErrorHandler, err error) *Event {
+	if handler == nil {
+		handler = rogerr.NewErrorHandler()
+	}
+	ev := &Event{
+		EventID:     newEventID(),
+		Timestamp:   time.Now().UTC(),
+		Platform:    "go",
+		Level:       "error",
+		Extra:       rogerr.JSONSafe(handler.Metadata(err)),
+		Fingerprint: []string{rogerr.Fingerprint(err)},
+	}
+	if err == nil {
+		return ev
+	}
+
+	if breadcrumbs := handler.Breadcrumbs(err); len(breadcrumbs) > 0 {
+		ev.Breadcrumbs = &Breadcrumbs{Values: make([]Breadcrumb, len(breadcrumbs))}
+		for i, b := range breadcrumbs {
+			ev.Breadcrumbs.Values[i] = Breadcrumb{
+				Timestamp: b.Time.UTC(),
+				Type:      "default",
+				Category:  b.Category,
+				Message:   b.Message,
+				Data:      rogerr.JSONSafe(b.Data),
+			}
+		}
+	}
+
+	values := []Exception{}
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		values = append([]Exception{{Type: rogerr.ErrorType(e), Value: e.Error()}}, values...)
+	}
+	if frames := handler.Stacktrace(err); len(frames) > 0 {
+		st := &Stacktrace{Frames: make([]Frame, len(frames))}
+		for i, f := range frames {
+			st.Frames[len(frames)-1-i] = Frame{Function: f.Function, AbsPath: f.File, Lineno: f.Line, InApp: f.InApp}
+		}
+		values[len(values)-1].Stacktrace = st
+	}
+	ev.Exception = &Exceptions{Values: values}
+	return ev
+}
+
+func newEventID() string {
+	id := make([]byte, 16)
+	rand.Read(id) //nolint:errcheck // crypto/rand.Read never returns an error
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,96 @@
+package sentry_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/kinbiko/rogerr"
+	"github.com/kinbiko/rogerr/sentry"
+)
+
+func TestNewEvent(t *testing.T) {
+	handler := rogerr.NewErrorHandler()
+	ctx := rogerr.WithMetadata(context.Background(), map[string]interface{}{"userID": 123, "nan": math.NaN()})
+	ctx = rogerr.Breadcrumb(ctx, "db", "SELECT users", map[string]interface{}{"rows": 0})
+	err := fmt.Errorf("fmt: %w", handler.Wrap(ctx, errors.New("low level"), "wrapped"))
+
+	ev := sentry.NewEvent(handler, err)
+
+	t.Run("event fields", func(t *testing.T) {
+		if len(ev.EventID) != 32 {
+			t.Errorf("expected a 32 character event ID but got %q", ev.EventID)
+		}
+		if ev.Platform != "go" || ev.Level != "error" || ev.Timestamp.IsZero() {
+			t.Errorf("unexpected event %+v", ev)
+		}
+		if len(ev.Fingerprint) != 1 || ev.Fingerprint[0] != rogerr.Fingerprint(err) {
+			t.Errorf("expected fingerprint %s but got %v", rogerr.Fingerprint(err), ev.Fingerprint)
+		}
+	})
+
+	t.Run("metadata as extra", func(t *testing.T) {
+		if ev.Extra["userID"] != 123 || ev.Extra["nan"] != "NaN" {
+			t.Errorf("unexpected extra %v", ev.Extra)
+		}
+	})
+
+	t.Run("breadcrumbs", func(t *testing.T) {
+		if ev.Breadcrumbs == nil || len(ev.Breadcrumbs.Values) != 1 {
+			t.Fatalf("expected 1 breadcrumb but got %+v", ev.Breadcrumbs)
+		}
+		if b := ev.Breadcrumbs.Values[0]; b.Category != "db" || b.Message != "SELECT users" || b.Type != "default" || b.Data["rows"] != 0 {
+			t.Errorf("unexpected breadcrumb %+v", b)
+		}
+	})
+
+	t.Run("exceptions from the cause chain", func(t *testing.T) {
+		values := ev.Exception.Values
+		if len(values) != 3 {
+			t.Fatalf("expected 3 exceptions but got %d", len(values))
+		}
+		for i, exp := range []string{"low level", "wrapped: low level", "fmt: wrapped: low level"} {
+			if values[i].Value != exp {
+				t.Errorf("expected exception %d to have value %q but got %q", i, exp, values[i].Value)
+			}
+		}
+		if values[0].Type != "*errors.errorString" {
+			t.Errorf("expected root cause type *errors.errorString but got %s", values[0].Type)
+		}
+		if values[1].Type != "rogerr.Error" {
+			t.Errorf("expected rogerr layer type rogerr.Error but got %s", values[1].Type)
+		}
+	})
+
+	t.Run("stacktrace on the outermost exception", func(t *testing.T) {
+		values := ev.Exception.Values
+		st := values[len(values)-1].Stacktrace
+		if st == nil || len(st.Frames) == 0 {
+			t.Fatal("expected stacktrace on the outermost exception")
+		}
+		if last := st.Frames[len(st.Frames)-1]; last.Function != "github.com/kinbiko/rogerr/sentry_test.TestNewEvent" || !last.InApp {
+			t.Errorf("expected the most recent frame last but got %+v", last)
+		}
+		if values[0].Stacktrace != nil {
+			t.Error("expected no stacktrace on inner exceptions")
+		}
+	})
+
+	t.Run("redacted by the given handler", func(t *testing.T) {
+		ev := sentry.NewEvent(rogerr.NewErrorHandler(rogerr.WithRedactedKeys("userID", "rows")), err)
+		if _, ok := ev.Extra["userID"]; ok {
+			t.Errorf("expected userID to be redacted but got %v", ev.Extra)
+		}
+		if _, ok := ev.Breadcrumbs.Values[0].Data["rows"]; ok {
+			t.Errorf("expected breadcrumb data to be redacted but got %v", ev.Breadcrumbs.Values[0].Data)
+		}
+	})
+
+	t.Run("nil handler and error", func(t *testing.T) {
+		if ev := sentry.NewEvent(nil, nil); ev.Exception != nil {
+			t.Errorf("expected no exceptions but got %+v", ev.Exception)
+		}
+	})
+}
@@ -0,0 +1,134 @@
+package sentry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DSN is a parsed Sentry Data Source Name, of the form
+// {scheme}://{public_key}@{host}{path}/{project_id}.
+type DSN struct {
+	Scheme    string
+	PublicKey string
+	Host      string
+	Path      string
+	ProjectID string
+}
+
+// ParseDSN parses the given Sentry DSN.
+func ParseDSN(dsn string) (*DSN, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DSN: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid DSN: unsupported scheme %q", u.Scheme)
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("invalid DSN: missing public key")
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid DSN: missing host")
+	}
+	path, projectID := "", strings.TrimSuffix(u.Path, "/")
+	if i := strings.LastIndex(projectID, "/"); i >= 0 {
+		path, projectID = projectID[:i], projectID[i+1:]
+	}
+	if projectID == "" {
+		return nil, fmt.Errorf("invalid DSN: missing project ID")
+	}
+	return &DSN{Scheme: u.Scheme, PublicKey: u.User.Username(), Host: u.Host, Path: path, ProjectID: projectID}, nil
+}
+
+// String returns the DSN in its textual form.
+func (d *DSN) String() string {
+	return fmt.Sprintf("%s://%s@%s%s/%s", d.Scheme, d.PublicKey, d.Host, d.Path, d.ProjectID)
+}
+
+// EnvelopeURL returns the URL that envelopes for this DSN are sent to.
+func (d *DSN) EnvelopeURL() string {
+	return fmt.Sprintf("%s://%s%s/api/%s/envelope/", d.Scheme, d.Host, d.Path, d.ProjectID)
+}
+
+// EncodeEnvelope encodes the given event as a Sentry envelope for the given DSN.
+func EncodeEnvelope(dsn *DSN, ev *Event) ([]byte, error) {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal event: %w", err)
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, header := range []interface{}{
+		map[string]string{"event_id": ev.EventID, "sent_at": time.Now().UTC().Format(time.RFC3339), "dsn": dsn.String()},
+		map[string]interface{}{"type": "event", "length": len(payload), "content_type": "application/json"},
+	} {
+		if err := enc.Encode(header); err != nil {
+			return nil, fmt.Errorf("unable to marshal envelope header: %w", err)
+		}
+	}
+	buf.Write(payload)
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// Transport sends events to a Sentry-compatible ingest endpoint.
+type Transport struct {
+	dsn    *DSN
+	client *http.Client
+}
+
+// TransportOption is a function that configures a Transport.
+type TransportOption func(*Transport)
+
+// WithHTTPClient configures the HTTP client used to send events.
+func WithHTTPClient(client *http.Client) TransportOption {
+	return func(t *Transport) {
+		t.client = client
+	}
+}
+
+// NewTransport creates a Transport that sends events to the endpoint of the given DSN.
+// By default, events are sent with a client that times out after 10 seconds.
+func NewTransport(dsn string, opts ...TransportOption) (*Transport, error) {
+	d, err := ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	t := &Transport{dsn: d, client: &http.Client{Timeout: 10 * time.Second}}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t, nil
+}
+
+// Send sends the given event as an envelope.
+// Returns an error if the event couldn't be delivered or was rejected.
+func (t *Transport) Send(ctx context.Context, ev *Event) error {
+	body, err := EncodeEnvelope(t.dsn, ev)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.dsn.EnvelopeURL(), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", fmt.Sprintf("Sentry sentry_version=7, sentry_client=rogerr, sentry_key=%s", t.dsn.PublicKey))
+
+	res, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to send event: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("event rejected with status %d", res.StatusCode)
+	}
+	return nil
+}
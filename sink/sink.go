@@ -0,0 +1,125 @@
+/*
+Package sink provides rogerr.Sink implementations for reporting errors with
+rogerr.ErrorHandler.Report:
+
+	memory := sink.NewMemory()
+	handler := rogerr.NewErrorHandler(rogerr.WithSinks(
+		sink.NewSlog(slog.Default()),
+		sink.NewJSON(file),
+		memory,
+	))
+	handler.Report(ctx, err)
+*/
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+
+	"github.com/kinbiko/rogerr"
+)
+
+// Slog is a rogerr.Sink that logs events with a slog.Logger.
+type Slog struct {
+	logger *slog.Logger
+}
+
+// NewSlog creates a Slog sink that logs events at error level with the given
+// logger, or with the default logger if nil.
+func NewSlog(logger *slog.Logger) *Slog {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Slog{logger: logger}
+}
+
+// Send logs the given event with its fingerprint, code, metadata,
+// breadcrumbs, and stacktrace as attributes.
+func (s *Slog) Send(ctx context.Context, event *rogerr.Event) error {
+	metadata := []interface{}{}
+	for k, v := range rogerr.NewMetadataSet(event.Metadata).All() {
+		metadata = append(metadata, slog.Any(k, v))
+	}
+
+	attrs := []slog.Attr{
+		slog.String("fingerprint", event.Fingerprint),
+		slog.String("code", event.Code.String()),
+	}
+	if len(metadata) > 0 {
+		attrs = append(attrs, slog.Group("metadata", metadata...))
+	}
+	if len(event.Breadcrumbs) > 0 {
+		attrs = append(attrs, slog.Any("breadcrumbs", event.Breadcrumbs))
+	}
+	if len(event.Stacktrace) > 0 {
+		stacktrace := make([]string, len(event.Stacktrace))
+		for i, f := range event.Stacktrace {
+			stacktrace[i] = fmt.Sprintf("%s (%s:%d)", f.Function, f.File, f.Line)
+		}
+		attrs = append(attrs, slog.Any("stacktrace", stacktrace))
+	}
+	s.logger.LogAttrs(ctx, slog.LevelError, event.Message, attrs...)
+	return nil
+}
+
+// JSON is a rogerr.Sink that writes events to an io.Writer as JSON, one per line.
+type JSON struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSON creates a JSON sink that writes events to w, e.g. an *os.File.
+func NewJSON(w io.Writer) *JSON {
+	return &JSON{w: w}
+}
+
+// Send writes the given event as a single line of JSON.
+func (s *JSON) Send(_ context.Context, event *rogerr.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("unable to marshal event: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("unable to write event: %w", err)
+	}
+	return nil
+}
+
+// Memory is a rogerr.Sink that keeps events in memory, e.g. for tests.
+type Memory struct {
+	mu     sync.Mutex
+	events []*rogerr.Event
+}
+
+// NewMemory creates an empty Memory sink.
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+// Send keeps the given event.
+func (s *Memory) Send(_ context.Context, event *rogerr.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	return nil
+}
+
+// Events returns the events kept so far, in the order they were sent.
+func (s *Memory) Events() []*rogerr.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*rogerr.Event(nil), s.events...)
+}
+
+// Reset discards the events kept so far.
+func (s *Memory) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = nil
+}
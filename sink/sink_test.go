@@ -0,0 +1,90 @@
+package sink_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/kinbiko/rogerr"
+	"github.com/kinbiko/rogerr/sink"
+)
+
+func report(t *testing.T, s rogerr.Sink) *rogerr.Event {
+	t.Helper()
+	var event *rogerr.Event
+	h := rogerr.NewErrorHandler(rogerr.WithSinks(s, rogerr.SinkFunc(func(_ context.Context, ev *rogerr.Event) error {
+		event = ev
+		return nil
+	})), rogerr.WithSinkErrorHandler(func(_ context.Context, _ *rogerr.Event, err error) {
+		t.Errorf("unexpected sink error: %v", err)
+	}))
+	ctx := rogerr.WithMetadata(context.Background(), map[string]interface{}{"b": 2, "a": 1})
+	h.Report(ctx, h.Wrap(rogerr.WithCode(ctx, rogerr.CodeNotFound), errors.New("no rows"), "user not found"))
+	return event
+}
+
+func TestSlog(t *testing.T) {
+	var buf bytes.Buffer
+	report(t, sink.NewSlog(slog.New(slog.NewJSONHandler(&buf, nil))))
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got["level"] != "ERROR" || got["msg"] != "user not found: no rows" || got["code"] != "not_found" {
+		t.Errorf("unexpected record: %s", buf.String())
+	}
+	if got["fingerprint"] == "" || got["stacktrace"] == nil {
+		t.Errorf("expected fingerprint and stacktrace attributes: %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), `"metadata":{"a":1,"b":2}`) {
+		t.Errorf("expected sorted metadata group: %s", buf.String())
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var buf bytes.Buffer
+	s := sink.NewJSON(&buf)
+	event := report(t, s)
+	if err := s.Send(context.Background(), event); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected one line per event but got %q", buf.String())
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got["message"] != "user not found: no rows" || got["fingerprint"] != event.Fingerprint {
+		t.Errorf("unexpected JSON: %s", lines[0])
+	}
+}
+
+func TestJSONWriteError(t *testing.T) {
+	if err := sink.NewJSON(failingWriter{}).Send(context.Background(), &rogerr.Event{}); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestMemory(t *testing.T) {
+	m := sink.NewMemory()
+	event := report(t, m)
+	if events := m.Events(); len(events) != 1 || events[0] != event {
+		t.Errorf("expected the reported event but got %v", events)
+	}
+	m.Reset()
+	if events := m.Events(); len(events) != 0 {
+		t.Errorf("expected no events after Reset but got %v", events)
+	}
+}
@@ -0,0 +1,73 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/kinbiko/rogerr"
+)
+
+// Webhook is a rogerr.Sink that posts events as JSON to an HTTP endpoint.
+type Webhook struct {
+	url     string
+	client  *http.Client
+	headers http.Header
+}
+
+// WebhookOption is a function that configures a Webhook.
+type WebhookOption func(*Webhook)
+
+// WithHTTPClient configures the HTTP client used to post events.
+func WithHTTPClient(client *http.Client) WebhookOption {
+	return func(w *Webhook) {
+		w.client = client
+	}
+}
+
+// WithHeader configures a header that is set on every request, e.g. for authentication.
+func WithHeader(key, value string) WebhookOption {
+	return func(w *Webhook) {
+		w.headers.Set(key, value)
+	}
+}
+
+// NewWebhook creates a Webhook that posts events to the given URL.
+// By default, events are posted with a client that times out after 10 seconds.
+func NewWebhook(url string, opts ...WebhookOption) *Webhook {
+	w := &Webhook{url: url, client: &http.Client{Timeout: 10 * time.Second}, headers: http.Header{}}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Send posts the given event.
+// Returns an error if the event couldn't be delivered or was rejected.
+func (w *Webhook) Send(ctx context.Context, event *rogerr.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("unable to marshal event: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("unable to create request: %w", err)
+	}
+	for k, v := range w.headers {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to send event: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("event rejected with status %d", res.StatusCode)
+	}
+	return nil
+}
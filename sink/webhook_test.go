@@ -0,0 +1,47 @@
+package sink_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kinbiko/rogerr"
+	"github.com/kinbiko/rogerr/sink"
+)
+
+func TestWebhook(t *testing.T) {
+	var got map[string]interface{}
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	event := report(t, sink.NewWebhook(srv.URL, sink.WithHTTPClient(srv.Client()), sink.WithHeader("Authorization", "Bearer token")))
+	if got["message"] != event.Message || got["code"] != "not_found" {
+		t.Errorf("unexpected payload: %v", got)
+	}
+	if header.Get("Authorization") != "Bearer token" || header.Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected headers: %v", header)
+	}
+}
+
+func TestWebhookRejected(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	if err := sink.NewWebhook(srv.URL).Send(context.Background(), &rogerr.Event{}); err == nil {
+		t.Error("expected an error for a rejected event")
+	}
+	if err := sink.NewWebhook("http://127.0.0.1:0").Send(context.Background(), &rogerr.Event{}); err == nil {
+		t.Error("expected an error for an unreachable endpoint")
+	}
+}
@@ -0,0 +1,219 @@
+/*
+Package slogerr provides a log/slog integration for rogerr.
+
+Wrap any slog.Handler with NewHandler, and records logged with an error
+attribute are enriched with the error's rogerr metadata and stacktrace,
+using OpenTelemetry semantic conventions for attribute names by default:
+
+	logger := slog.New(slogerr.NewHandler(slog.NewJSONHandler(os.Stdout, nil)))
+	logger.ErrorContext(ctx, "request failed", slog.Any("error", err))
+*/
+package slogerr
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/kinbiko/rogerr"
+)
+
+// AttributeNames holds the names of the attributes added to enriched records.
+type AttributeNames struct {
+	ExceptionType       string // The type of the root cause of the error
+	ExceptionMessage    string // The full error message
+	ExceptionStacktrace string // The stacktrace frames of the error
+	CodeFunction        string // The function name of a stacktrace frame
+	CodeFilepath        string // The file path of a stacktrace frame
+	CodeLineno          string // The line number of a stacktrace frame
+	CodeNamespace       string // Whether a stacktrace frame is "application" or "dependency" code
+	MetadataPrefix      string // Prepended to the key of every metadatum
+	Breadcrumbs         string // The breadcrumbs of the error
+}
+
+// DefaultAttributeNames returns attribute names following OpenTelemetry semantic conventions.
+func DefaultAttributeNames() AttributeNames {
+	return AttributeNames{
+		ExceptionType:       "exception.type",
+		ExceptionMessage:    "exception.message",
+		ExceptionStacktrace: "exception.stacktrace",
+		CodeFunction:        "code.function",
+		CodeFilepath:        "code.filepath",
+		CodeLineno:          "code.lineno",
+		CodeNamespace:       "code.namespace",
+		MetadataPrefix:      "",
+		Breadcrumbs:         "breadcrumbs",
+	}
+}
+
+// Handler is a slog.Handler middleware that enriches records with rogerr
+// metadata and stacktraces before passing them on to the next handler.
+type Handler struct {
+	next   slog.Handler
+	errors *rogerr.ErrorHandler
+	names  AttributeNames
+	nested bool
+}
+
+// Option is a function that configures a Handler.
+type Option func(*Handler)
+
+// WithErrorHandler configures the rogerr.ErrorHandler used to extract
+// metadata and stacktraces from logged errors.
+func WithErrorHandler(errorHandler *rogerr.ErrorHandler) Option {
+	return func(h *Handler) {
+		h.errors = errorHandler
+	}
+}
+
+// WithAttributeNames configures the names of the attributes added to records.
+func WithAttributeNames(names AttributeNames) Option {
+	return func(h *Handler) {
+		h.names = names
+	}
+}
+
+// WithNestedMetadata configures whether the metadata of each rogerr.Namespace
+// is added as a group of its own, e.g. "mylib":{"id":1}, rather than with
+// qualified keys, e.g. "mylib.id":1.
+func WithNestedMetadata(enabled bool) Option {
+	return func(h *Handler) {
+		h.nested = enabled
+	}
+}
+
+// NewHandler creates a new Handler that passes enriched records on to next.
+// By default, attribute names follow OpenTelemetry semantic conventions.
+func NewHandler(next slog.Handler, opts ...Option) *Handler {
+	h := &Handler{
+		next:   next,
+		errors: rogerr.NewErrorHandler(),
+		names:  DefaultAttributeNames(),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Enabled reports whether the next handler handles records at the given level.
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle adds the metadata from the given context to the record, redacted
+// according to the configured rogerr.ErrorHandler.
+// If the record has an error attribute, it's replaced by the error's message,
+// type, stacktrace, breadcrumbs, and metadata, so that the error is never
+// logged with another handler's redaction rules. Error metadata takes
+// precedence over context metadata with the same key.
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error { //nolint:gocritic // signature defined by slog.Handler
+	metadata := h.contextMetadata(ctx)
+	var err error
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		if e, ok := a.Value.Any().(error); ok && err == nil {
+			err = e
+		} else {
+			attrs = append(attrs, a)
+		}
+		return true
+	})
+
+	r = slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.AddAttrs(attrs...)
+	if err != nil {
+		r.AddAttrs(h.errorAttrs(err)...)
+		for k, v := range h.errorMetadata(err) {
+			if metadata == nil {
+				metadata = map[string]interface{}{}
+			}
+			group, isGroup := v.(map[string]interface{})
+			existing, hasGroup := metadata[k].(map[string]interface{})
+			if h.nested && isGroup && hasGroup {
+				for gk, gv := range group {
+					existing[gk] = gv
+				}
+				continue
+			}
+			metadata[k] = v
+		}
+	}
+	r.AddAttrs(h.metadataAttrs(metadata)...)
+	return h.next.Handle(ctx, r)
+}
+
+// WithAttrs returns a new Handler whose next handler has the given attributes.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &Handler{next: h.next.WithAttrs(attrs), errors: h.errors, names: h.names, nested: h.nested}
+}
+
+// WithGroup returns a new Handler whose next handler has the given group.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{next: h.next.WithGroup(name), errors: h.errors, names: h.names, nested: h.nested}
+}
+
+func (h *Handler) errorAttrs(err error) []slog.Attr {
+	attrs := []slog.Attr{
+		slog.String(h.names.ExceptionType, rogerr.ErrorType(rogerr.RootCause(err))),
+		slog.String(h.names.ExceptionMessage, err.Error()),
+	}
+
+	if breadcrumbs := h.errors.Breadcrumbs(err); len(breadcrumbs) > 0 {
+		attrs = append(attrs, slog.Any(h.names.Breadcrumbs, breadcrumbs))
+	}
+
+	frames := h.errors.Stacktrace(err)
+	if len(frames) == 0 {
+		return attrs
+	}
+	frameData := make([]map[string]interface{}, len(frames))
+	for i, frame := range frames {
+		namespace := "dependency"
+		if frame.InApp {
+			namespace = "application"
+		}
+		frameData[i] = map[string]interface{}{
+			h.names.CodeFunction:  frame.Function,
+			h.names.CodeFilepath:  frame.File,
+			h.names.CodeLineno:    frame.Line,
+			h.names.CodeNamespace: namespace,
+		}
+	}
+	return append(attrs, slog.Any(h.names.ExceptionStacktrace, frameData))
+}
+
+func (h *Handler) contextMetadata(ctx context.Context) map[string]interface{} {
+	if h.nested {
+		return h.errors.NestedContextMetadata(ctx)
+	}
+	return h.errors.ContextMetadata(ctx)
+}
+
+func (h *Handler) errorMetadata(err error) map[string]interface{} {
+	if h.nested {
+		return h.errors.NestedMetadata(err)
+	}
+	return h.errors.Metadata(err)
+}
+
+func (h *Handler) metadataAttrs(metadata map[string]interface{}) []slog.Attr {
+	attrs := sortedAttrs(metadata, h.nested)
+	for i := range attrs {
+		attrs[i].Key = h.names.MetadataPrefix + attrs[i].Key
+	}
+	return attrs
+}
+
+// sortedAttrs returns the given metadata as attributes sorted by key.
+// If groups is set, nested maps are given as groups.
+func sortedAttrs(metadata map[string]interface{}, groups bool) []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(metadata))
+	for k, v := range rogerr.NewMetadataSet(metadata).All() {
+		if group, ok := v.(map[string]interface{}); ok && groups {
+			attrs = append(attrs, slog.Attr{Key: k, Value: slog.GroupValue(sortedAttrs(group, false)...)})
+		} else {
+			attrs = append(attrs, slog.Any(k, v))
+		}
+	}
+	return attrs
+}
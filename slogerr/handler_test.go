@@ -0,0 +1,140 @@
+package slogerr_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"github.com/kinbiko/rogerr"
+	"github.com/kinbiko/rogerr/slogerr"
+)
+
+func logJSON(t *testing.T, ctx context.Context, opts []slogerr.Option, args ...any) map[string]interface{} {
+	t.Helper()
+	var buf bytes.Buffer
+	logger := slog.New(slogerr.NewHandler(slog.NewJSONHandler(&buf, nil), opts...))
+	logger.ErrorContext(ctx, "something failed", args...)
+
+	got := map[string]interface{}{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("unable to unmarshal log output %s: %v", buf.String(), err)
+	}
+	return got
+}
+
+func TestHandler(t *testing.T) {
+	ctx := rogerr.WithMetadatum(context.Background(), "requestID", "abc")
+	err := rogerr.NewErrorHandler().Wrap(rogerr.Breadcrumb(rogerr.WithMetadatum(ctx, "userID", 123), "db", "SELECT users", nil), errors.New("low level"), "wrapped")
+
+	t.Run("enriches records with error attributes", func(t *testing.T) {
+		got := logJSON(t, context.Background(), nil, slog.Any("error", err))
+
+		for k, v := range map[string]interface{}{
+			"exception.type":    "*errors.errorString",
+			"exception.message": "wrapped: low level",
+			"requestID":         "abc",
+			"userID":            float64(123),
+		} {
+			if got[k] != v {
+				t.Errorf("expected attribute '%s' to be <%v> but was <%v>", k, v, got[k])
+			}
+		}
+
+		breadcrumbs, ok := got["breadcrumbs"].([]interface{})
+		if !ok || len(breadcrumbs) != 1 || breadcrumbs[0].(map[string]interface{})["message"] != "SELECT users" {
+			t.Errorf("expected breadcrumbs but got %v", got["breadcrumbs"])
+		}
+
+		frames, ok := got["exception.stacktrace"].([]interface{})
+		if !ok || len(frames) == 0 {
+			t.Fatalf("expected stacktrace frames but got %v", got["exception.stacktrace"])
+		}
+		frame := frames[0].(map[string]interface{})
+		for _, k := range []string{"code.function", "code.filepath", "code.lineno", "code.namespace"} {
+			if _, ok := frame[k]; !ok {
+				t.Errorf("expected frame to have attribute '%s' but got %v", k, frame)
+			}
+		}
+	})
+
+	t.Run("adds context metadata when no error is logged", func(t *testing.T) {
+		got := logJSON(t, ctx, nil)
+		if got["requestID"] != "abc" {
+			t.Errorf("expected requestID attribute but got %v", got)
+		}
+		if _, ok := got["exception.message"]; ok {
+			t.Errorf("expected no exception attributes but got %v", got)
+		}
+	})
+
+	t.Run("error metadata takes precedence over context metadata", func(t *testing.T) {
+		got := logJSON(t, rogerr.WithMetadatum(context.Background(), "userID", 0), nil, slog.Any("error", err))
+		if got["userID"] != float64(123) {
+			t.Errorf("expected userID from error but got %v", got["userID"])
+		}
+	})
+
+	t.Run("nested metadata", func(t *testing.T) {
+		ctx := rogerr.Namespace("mylib").WithMetadatum(context.Background(), "id", "ctx")
+		nsErr := rogerr.NewErrorHandler().Wrap(rogerr.Namespace("mylib").WithMetadatum(ctx, "retries", 3), nil, "oops")
+		got := logJSON(t, ctx, []slogerr.Option{slogerr.WithNestedMetadata(true)}, slog.Any("error", nsErr))
+		exp := map[string]interface{}{"id": "ctx", "retries": float64(3)}
+		if group, _ := got["mylib"].(map[string]interface{}); !reflect.DeepEqual(group, exp) {
+			t.Errorf("expected mylib group %v but got %v", exp, got)
+		}
+		if _, ok := got["mylib.id"]; ok {
+			t.Errorf("expected no qualified keys but got %v", got)
+		}
+	})
+
+	t.Run("configurable attribute names", func(t *testing.T) {
+		names := slogerr.DefaultAttributeNames()
+		names.ExceptionMessage = "error.message"
+		names.MetadataPrefix = "md."
+		got := logJSON(t, context.Background(), []slogerr.Option{
+			slogerr.WithAttributeNames(names),
+			slogerr.WithErrorHandler(rogerr.NewErrorHandler(rogerr.WithStacktrace(false))),
+		}, slog.Any("error", err))
+
+		if got["error.message"] != "wrapped: low level" {
+			t.Errorf("expected renamed message attribute but got %v", got)
+		}
+		if got["md.requestID"] != "abc" {
+			t.Errorf("expected prefixed metadata attribute but got %v", got)
+		}
+	})
+
+	t.Run("redacted by the given error handler", func(t *testing.T) {
+		got := logJSON(t, context.Background(), []slogerr.Option{
+			slogerr.WithErrorHandler(rogerr.NewErrorHandler(rogerr.WithRedactedKeys("userID"))),
+		}, slog.Any("error", err))
+		if _, ok := got["userID"]; ok || got["requestID"] != "abc" {
+			t.Errorf("expected userID to be redacted but got %v", got)
+		}
+		if _, ok := got["error"]; ok {
+			t.Errorf("expected the error attribute to be replaced but got %v", got)
+		}
+		if output, _ := json.Marshal(got); bytes.Contains(output, []byte("userID")) {
+			t.Errorf("expected userID to be redacted from the whole output but got %s", output)
+		}
+	})
+
+	t.Run("WithAttrs and WithGroup keep enriching", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slogerr.NewHandler(slog.NewJSONHandler(&buf, nil))).With("service", "demo").WithGroup("g")
+		logger.ErrorContext(ctx, "something failed")
+
+		got := map[string]interface{}{}
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		group, ok := got["g"].(map[string]interface{})
+		if got["service"] != "demo" || !ok || group["requestID"] != "abc" {
+			t.Errorf("unexpected output %v", got)
+		}
+	})
+}
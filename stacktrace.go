@@ -1,35 +1,204 @@
 package rogerr
 
 import (
+	"path"
 	"runtime"
 	"runtime/debug"
 	"strings"
+	"sync"
 )
 
 // Frame represents a single frame in a stacktrace.
 type Frame struct {
-	File     string // Full file path
-	Line     int    // Line number
-	Function string // Function or method name
-	InApp    bool   // true if application code, false if dependency
+	File      string `json:"file"`                // Full file path
+	Line      int    `json:"line"`                // Line number
+	Function  string `json:"function"`            // Function or method name
+	InApp     bool   `json:"inApp"`               // true if application code, false if dependency
+	Truncated bool   `json:"truncated,omitempty"` // true on the last frame if deeper frames were dropped
 }
 
-// getModulePath returns the application module path for determining if frames are in-app.
-func getModulePath() string {
-	if bi, ok := debug.ReadBuildInfo(); ok {
-		return bi.Main.Path
+const (
+	// defaultMaxStackDepth is the default maximum number of frames in a stacktrace.
+	defaultMaxStackDepth = 64
+	// rogerrFrameAllowance is the number of extra program counters captured to
+	// make room for the rogerr frames that are trimmed from the top of the stack.
+	rogerrFrameAllowance = 16
+)
+
+// stackRules holds the configuration for which frames make up a stacktrace.
+type stackRules struct {
+	maxDepth   int
+	callerSkip int
+	excluded   []string
+}
+
+// WithMaxStackDepth configures the maximum number of frames in a stacktrace.
+// Stacktraces with more frames are truncated, which is flagged by the
+// Truncated field of the last frame. Defaults to 64. Values below 1 are ignored.
+func WithMaxStackDepth(depth int) Option {
+	return func(h *ErrorHandler) {
+		if depth > 0 {
+			h.stack.maxDepth = depth
+		}
+	}
+}
+
+// WithCallerSkip configures the number of frames to skip at the top of
+// stacktraces, in addition to rogerr's own frames. This is useful for hiding
+// helper functions that wrap ErrorHandler.Wrap, similarly to testing.T.Helper.
+// Values below 0 are ignored.
+func WithCallerSkip(skip int) Option {
+	return func(h *ErrorHandler) {
+		if skip >= 0 {
+			h.stack.callerSkip = skip
+		}
+	}
+}
+
+// WithExcludedFrames configures packages and functions whose frames are left
+// out of stacktraces, e.g. "runtime" and "testing". Packages are given as with
+// WithInAppPackages. Functions are given by their full name as it appears in
+// Frame.Function, e.g. "main.wrapHelper", or as path.Match glob patterns,
+// e.g. "github.com/myorg/mylib.wrap*".
+func WithExcludedFrames(patterns ...string) Option {
+	return func(h *ErrorHandler) {
+		h.stack.excluded = append(h.stack.excluded, patterns...)
+	}
+}
+
+// capacity returns the number of program counters to capture.
+// One more than the number of frames that could be kept is captured, in
+// order to detect whether the stacktrace has been truncated.
+func (r *stackRules) capacity() int {
+	return r.maxDepth + r.callerSkip + rogerrFrameAllowance + 1
+}
+
+// apply skips, excludes, and truncates the given frames, which have already
+// had rogerr's own frames trimmed. full indicates that the captured program
+// counters were truncated, so deeper frames may have been missed.
+func (r *stackRules) apply(frames []Frame, full bool) []Frame {
+	if r.callerSkip >= len(frames) {
+		return nil
+	}
+	frames = frames[r.callerSkip:]
+
+	if len(r.excluded) > 0 {
+		kept := make([]Frame, 0, len(frames))
+		for _, f := range frames {
+			if !matchesPackage(r.excluded, packagePath(f.Function)) && !matchesFunction(r.excluded, f.Function) {
+				kept = append(kept, f)
+			}
+		}
+		frames = kept
+	}
+
+	if len(frames) > r.maxDepth {
+		frames, full = frames[:r.maxDepth], true
+	}
+	if full && len(frames) > 0 {
+		frames[len(frames)-1].Truncated = true
+	}
+	return frames
+}
+
+// inAppRules holds the configuration for classifying frames as in-app,
+// in addition to the default of the main module and package.
+type inAppRules struct {
+	inApp           []string
+	notInApp        []string
+	replacedModules bool
+}
+
+// WithInAppPackages configures packages whose frames are in-app.
+// Packages may be given as import path prefixes, e.g. "github.com/myorg/mylib",
+// which include their subpackages, or as path.Match glob patterns, e.g.
+// "github.com/myorg/*", which match a package or any of its parents.
+func WithInAppPackages(patterns ...string) Option {
+	return func(h *ErrorHandler) {
+		h.inApp.inApp = append(h.inApp.inApp, patterns...)
+	}
+}
+
+// WithNotInAppPackages configures packages whose frames are not in-app,
+// even if they would otherwise be, e.g. vendored forks of third-party code
+// within the main module. Packages are given as with WithInAppPackages,
+// and take precedence over them.
+func WithNotInAppPackages(patterns ...string) Option {
+	return func(h *ErrorHandler) {
+		h.inApp.notInApp = append(h.inApp.notInApp, patterns...)
+	}
+}
+
+// WithReplacedModulesInApp configures whether frames from modules that are
+// replaced in the main module's go.mod, e.g. with a local path, are in-app.
+func WithReplacedModulesInApp(enabled bool) Option {
+	return func(h *ErrorHandler) {
+		h.inApp.replacedModules = enabled
+	}
+}
+
+// configured reports whether any rules beyond the defaults have been configured.
+func (r *inAppRules) configured() bool {
+	return len(r.inApp) > 0 || len(r.notInApp) > 0 || r.replacedModules
+}
+
+// classifier returns a function that reports whether a frame's function is in-app.
+func (r *inAppRules) classifier() func(function string) bool {
+	bi, _ := readBuildInfo()
+	modulePath := ""
+	inApp := r.inApp
+	if bi != nil {
+		modulePath = bi.Main.Path
+		if r.replacedModules {
+			inApp = append(replacedModules(bi), inApp...)
+		}
+	}
+	return func(function string) bool {
+		pkg := packagePath(function)
+		if matchesPackage(r.notInApp, pkg) {
+			return false
+		}
+		if matchesPackage(inApp, pkg) {
+			return true
+		}
+		return isInApp(function, modulePath)
+	}
+}
+
+// replacedModules returns the paths of the modules that are replaced in the build.
+func replacedModules(bi *debug.BuildInfo) []string {
+	paths := []string{}
+	for _, dep := range bi.Deps {
+		if dep.Replace != nil {
+			paths = append(paths, dep.Path)
+		}
 	}
-	return ""
+	return paths
 }
 
-// captureStacktrace captures the current call stack, excluding rogerr internal frames.
-func captureStacktrace(modulePath string) []Frame {
-	const maxFrames = 64
-	ptrs := [maxFrames]uintptr{}
+// readBuildInfo reads the build info of the running binary. It never changes,
+// so it's only read once.
+var readBuildInfo = sync.OnceValues(debug.ReadBuildInfo) //nolint:gochecknoglobals // cache of immutable process info
 
-	// Skip 0 frames as we'll filter manually
-	pcs := ptrs[0:runtime.Callers(0, ptrs[:])]
+// callers captures up to the given number of program counters of the current
+// call stack, and whether they filled the whole capacity, i.e. whether deeper
+// frames may have been missed. They are resolved into Frames with
+// resolveFrames only when needed.
+func callers(capacity int) ([]uintptr, bool) {
+	pcs := make([]uintptr, capacity)
 
+	// Skip only runtime.Callers itself, as frames are filtered after resolution
+	n := runtime.Callers(1, pcs)
+
+	// Copy to the exact length, so that the unused capacity isn't retained for
+	// as long as the error is.
+	exact := make([]uintptr, n)
+	copy(exact, pcs)
+	return exact, n == capacity
+}
+
+// resolveFrames resolves the given program counters into Frames.
+func resolveFrames(pcs []uintptr, isInApp func(string) bool) []Frame {
 	allFrames := make([]Frame, 0, len(pcs))
 	iter := runtime.CallersFrames(pcs)
 
@@ -39,15 +208,19 @@ func captureStacktrace(modulePath string) []Frame {
 			File:     frame.File,
 			Line:     frame.Line,
 			Function: frame.Function,
-			InApp:    isInApp(frame.Function, modulePath), // Determine if this is application code
+			InApp:    isInApp(frame.Function), // Determine if this is application code
 		})
 
 		if !more {
 			break
 		}
 	}
+	return allFrames
+}
 
-	// Now filter out rogerr frames, but keep everything after the last rogerr frame
+// trimRogerrFrames removes rogerr internal frames from the top of the given frames.
+func trimRogerrFrames(allFrames []Frame) []Frame {
+	// Filter out rogerr frames, but keep everything after the last rogerr frame
 	lastRogerrIndex := -1
 	for i, frame := range allFrames {
 		// Only filter out the main rogerr package, not internal modules
@@ -65,6 +238,26 @@ func captureStacktrace(modulePath string) []Frame {
 	return allFrames
 }
 
+// trimPanicFrames removes the frames of a deferred function and the runtime
+// from the top of the given frames of a panicking goroutine, so that they
+// start at the frame that panicked.
+// Falls back to trimRogerrFrames if the goroutine wasn't panicking.
+func trimPanicFrames(allFrames []Frame) []Frame {
+	for i, frame := range allFrames {
+		if frame.Function != "runtime.gopanic" {
+			continue
+		}
+		// Runtime errors such as nil pointer dereferences panic from within
+		// runtime helpers, which aren't interesting to anyone but the runtime.
+		i++
+		for i < len(allFrames) && strings.HasPrefix(allFrames[i].Function, "runtime.") {
+			i++
+		}
+		return allFrames[i:]
+	}
+	return trimRogerrFrames(allFrames)
+}
+
 // isInApp determines if a function belongs to the application or a dependency.
 func isInApp(function, modulePath string) bool {
 	if modulePath == "" {
@@ -80,3 +273,44 @@ func isInApp(function, modulePath string) bool {
 	// Check if function belongs to the app module
 	return strings.HasPrefix(function, modulePath)
 }
+
+// packagePath returns the import path of the package of the given function,
+// e.g. "github.com/example/myapp/pkg" for "github.com/example/myapp/pkg.(*T).Method".
+func packagePath(function string) string {
+	lastSlash := strings.LastIndex(function, "/")
+	if dot := strings.Index(function[lastSlash+1:], "."); dot >= 0 {
+		function = function[:lastSlash+1+dot]
+	}
+	// The runtime escapes dots in the last element of the path, e.g. gopkg.in/yaml%2ev3
+	return strings.ReplaceAll(function, "%2e", ".")
+}
+
+// matchesFunction reports whether the given function name matches any of the
+// given names or path.Match glob patterns.
+func matchesFunction(patterns []string, function string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, function); ok || pattern == function {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesPackage reports whether the given package matches any of the given
+// import path prefixes or glob patterns.
+func matchesPackage(patterns []string, pkg string) bool {
+	for _, pattern := range patterns {
+		if !strings.ContainsAny(pattern, `*?[\`) {
+			if pkg == pattern || strings.HasPrefix(pkg, strings.TrimSuffix(pattern, "/")+"/") {
+				return true
+			}
+			continue
+		}
+		for p := pkg; p != "."; p = path.Dir(p) {
+			if ok, _ := path.Match(pattern, p); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -2,7 +2,9 @@ package rogerr
 
 import (
 	"context"
+	"errors"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -15,7 +17,7 @@ func TestStacktraceCapture(t *testing.T) {
 		t.Fatal("expected error")
 	}
 
-	for _, frame := range err.(*rError).stacktrace {
+	for _, frame := range err.(*rError).frames() {
 		if frame.File == "" {
 			t.Error("expected file to be populated")
 		}
@@ -28,13 +30,40 @@ func TestStacktraceCapture(t *testing.T) {
 	}
 }
 
+func TestLazyStacktrace(t *testing.T) {
+	err := NewErrorHandler().Wrap(context.Background(), nil, "test error").(*rError)
+	if len(err.pcs) == 0 || err.stacktrace != nil {
+		t.Fatal("expected only program counters to be captured when wrapping")
+	}
+	if len(err.pcs) != cap(err.pcs) || err.truncated {
+		t.Errorf("expected exactly the captured program counters to be retained but got %d of capacity %d", len(err.pcs), cap(err.pcs))
+	}
+
+	var wg sync.WaitGroup
+	results := make([][]Frame, 10)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = NewErrorHandler().Stacktrace(err)
+		}()
+	}
+	wg.Wait()
+
+	for _, frames := range results {
+		if len(frames) == 0 || &frames[0] != &results[0][0] {
+			t.Fatal("expected the stacktrace to be resolved once and shared")
+		}
+	}
+}
+
 func TestFrameFiltering(t *testing.T) {
 	err := NewErrorHandler().Wrap(context.Background(), nil, "test error")
 	if err == nil {
 		t.Fatal("expected error")
 	}
 
-	for _, frame := range err.(*rError).stacktrace {
+	for _, frame := range err.(*rError).frames() {
 		if strings.Contains(frame.Function, "github.com/kinbiko/rogerr") {
 			t.Errorf("found rogerr internal frame: %s", frame.Function)
 		}
@@ -67,3 +96,153 @@ func TestIsInApp(t *testing.T) {
 		}
 	})
 }
+
+func TestPackagePath(t *testing.T) {
+	for function, exp := range map[string]string{
+		"main.main":                               "main",
+		"fmt.Printf":                              "fmt",
+		"github.com/example/myapp/pkg.Do":         "github.com/example/myapp/pkg",
+		"github.com/example/myapp/pkg.(*T).Do":    "github.com/example/myapp/pkg",
+		"github.com/example/my.app/pkg.T.Do":      "github.com/example/my.app/pkg",
+		"github.com/example/myapp.Do.func1":       "github.com/example/myapp",
+		"github.com/example/myapp.Map[...].Get":   "github.com/example/myapp",
+		"gopkg.in/yaml%2ev3.(*decoder).unmarshal": "gopkg.in/yaml.v3",
+	} {
+		t.Run(function, func(t *testing.T) {
+			if got := packagePath(function); got != exp {
+				t.Errorf("packagePath(%q) = %q, expected %q", function, got, exp)
+			}
+		})
+	}
+}
+
+func TestInAppRules(t *testing.T) {
+	h := NewErrorHandler(
+		WithInAppPackages("github.com/example/mylib", "github.com/myorg/*"),
+		WithNotInAppPackages("github.com/kinbiko/rogerr/vendored", "github.com/myorg/fork*"),
+	)
+	isInApp := h.inApp.classifier()
+
+	for name, tc := range map[string]struct {
+		function string
+		expected bool
+	}{
+		"in-app prefix":                    {"github.com/example/mylib.Do", true},
+		"in-app prefix subpackage":         {"github.com/example/mylib/sub.Do", true},
+		"in-app prefix is path-aware":      {"github.com/example/mylibrary.Do", false},
+		"in-app glob":                      {"github.com/myorg/service/internal/db.Query", true},
+		"not-in-app glob wins over in-app": {"github.com/myorg/forked/lib.Do", false},
+		"not-in-app wins over main module": {"github.com/kinbiko/rogerr/vendored/lib.Do", false},
+		"main package is still in-app":     {"main.main", true},
+		"unmatched dependency":             {"github.com/external/lib.Function", false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got := isInApp(tc.function); got != tc.expected {
+				t.Errorf("isInApp(%q) = %v, expected %v", tc.function, got, tc.expected)
+			}
+		})
+	}
+
+	t.Run("Stacktrace applies the extracting handler's rules", func(t *testing.T) {
+		err := &rError{stacktrace: []Frame{{Function: "github.com/example/mylib.Do"}}}
+		if NewErrorHandler().Stacktrace(err)[0].InApp {
+			t.Error("expected frame classified at capture time to be kept by a handler without rules")
+		}
+		if !h.Stacktrace(err)[0].InApp {
+			t.Error("expected frame to be reclassified by a handler with rules")
+		}
+		if err.stacktrace[0].InApp {
+			t.Error("expected the error's own frames to be unchanged")
+		}
+	})
+}
+
+func BenchmarkWrap(b *testing.B) {
+	ctx := context.Background()
+	err := errors.New("oops")
+
+	for name, tc := range map[string]struct {
+		handler *ErrorHandler
+		resolve bool
+	}{
+		"without stacktrace":            {handler: NewErrorHandler(WithStacktrace(false))},
+		"with stacktrace":               {handler: NewErrorHandler()},
+		"with stacktrace then resolved": {handler: NewErrorHandler(), resolve: true},
+	} {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				wrapped := tc.handler.Wrap(ctx, err, "wrapped")
+				if tc.resolve {
+					tc.handler.Stacktrace(wrapped)
+				}
+			}
+		})
+	}
+}
+
+func TestStackRules(t *testing.T) {
+	frames := func(functions ...string) []Frame {
+		fs := make([]Frame, len(functions))
+		for i, f := range functions {
+			fs[i] = Frame{Function: f}
+		}
+		return fs
+	}
+	functions := func(fs []Frame) string {
+		names := make([]string, len(fs))
+		for i, f := range fs {
+			names[i] = f.Function
+			if f.Truncated {
+				names[i] += "(truncated)"
+			}
+		}
+		return strings.Join(names, ",")
+	}
+	stack := []string{"main.helper", "main.run", "testing.tRunner", "main.main", "runtime.main", "runtime.goexit"}
+
+	for name, tc := range map[string]struct {
+		opts []Option
+		full bool
+		exp  string
+	}{
+		"defaults":             {exp: "main.helper,main.run,testing.tRunner,main.main,runtime.main,runtime.goexit"},
+		"caller skip":          {opts: []Option{WithCallerSkip(1)}, exp: "main.run,testing.tRunner,main.main,runtime.main,runtime.goexit"},
+		"caller skip too deep": {opts: []Option{WithCallerSkip(10)}, exp: ""},
+		"invalid caller skip":  {opts: []Option{WithCallerSkip(-1)}, exp: "main.helper,main.run,testing.tRunner,main.main,runtime.main,runtime.goexit"},
+		"excluded frames":      {opts: []Option{WithExcludedFrames("runtime", "testing")}, exp: "main.helper,main.run,main.main"},
+		"excluded functions":   {opts: []Option{WithExcludedFrames("main.helper", "runtime.g*")}, exp: "main.run,testing.tRunner,main.main,runtime.main"},
+		"max depth":            {opts: []Option{WithMaxStackDepth(2)}, exp: "main.helper,main.run(truncated)"},
+		"invalid max depth":    {opts: []Option{WithMaxStackDepth(0)}, exp: "main.helper,main.run,testing.tRunner,main.main,runtime.main,runtime.goexit"},
+		"captured stack full":  {full: true, exp: "main.helper,main.run,testing.tRunner,main.main,runtime.main,runtime.goexit(truncated)"},
+		"all options": {
+			opts: []Option{WithCallerSkip(1), WithExcludedFrames("testing"), WithMaxStackDepth(2)},
+			exp:  "main.run,main.main(truncated)",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			h := NewErrorHandler(tc.opts...)
+			if got := functions(h.stack.apply(frames(stack...), tc.full)); got != tc.exp {
+				t.Errorf("expected frames\n%s\nbut got\n%s", tc.exp, got)
+			}
+		})
+	}
+
+	t.Run("applied to captured stacktraces", func(t *testing.T) {
+		h := NewErrorHandler(WithMaxStackDepth(1))
+		got := h.Stacktrace(h.Wrap(context.Background(), nil, "oops"))
+		if len(got) != 1 || !got[0].Truncated {
+			t.Errorf("expected a single truncated frame but got %+v", got)
+		}
+
+		h = NewErrorHandler(WithCallerSkip(-1 << 62))
+		if got := h.Stacktrace(h.Wrap(context.Background(), nil, "oops")); len(got) == 0 {
+			t.Error("expected a negative caller skip to be ignored")
+		}
+
+		h = NewErrorHandler(WithExcludedFrames("testing", "runtime"))
+		if got := h.Stacktrace(h.Wrap(context.Background(), nil, "oops")); len(got) != 0 {
+			t.Errorf("expected all frames to be excluded but got %+v", got)
+		}
+	})
+}